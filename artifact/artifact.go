@@ -0,0 +1,147 @@
+// Package artifact exposes the cookbooks shipped in build artifacts, such as `berks package`
+// and `chef export` tarballs or `chef export` directories, as regular cookbook paths.
+package artifact
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Is reports whether the cookbook path p, as found in fsys, is an artifact rather than
+// a directory of cookbooks: a .tgz or .tar.gz archive, or a `chef export` directory.
+func Is(fsys fs.FS, p string) bool {
+	if strings.HasSuffix(p, ".tgz") || strings.HasSuffix(p, ".tar.gz") {
+		return true
+	}
+
+	info, err := fs.Stat(fsys, path.Join(p, "cookbook_artifacts"))
+
+	return err == nil && info.IsDir()
+}
+
+// Open indexes the cookbooks found in the artifact stored at p in fsys, returning a filesystem
+// where each cookbook's metadata.json is found at "<cookbook_name>/metadata.json", regardless
+// of how the artifact lays cookbooks out. Archives are read in memory, without extracting them.
+func Open(fsys fs.FS, p string) (fs.FS, error) {
+	if !strings.HasSuffix(p, ".tgz") && !strings.HasSuffix(p, ".tar.gz") {
+		return openDir(fsys, p)
+	}
+
+	f, err := fsys.Open(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed opening artifact: %w", err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed decompressing %q: %w", p, err)
+	}
+
+	index := make(memFS)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed reading %q: %w", p, err)
+		}
+
+		if hdr.Typeflag != tar.TypeReg || path.Base(hdr.Name) != "metadata.json" {
+			continue
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("failed reading %q in %q: %w", hdr.Name, p, err)
+		}
+
+		if err := index.add(hdr.Name, data); err != nil {
+			return nil, fmt.Errorf("failed indexing %q: %w", p, err)
+		}
+	}
+
+	return index, nil
+}
+
+// openDir indexes the cookbooks of a `chef export` directory, which are stored in
+// cookbook_artifacts/<cookbook_name>-<identifier>.
+func openDir(fsys fs.FS, p string) (fs.FS, error) {
+	matches, err := fs.Glob(fsys, path.Join(p, "cookbook_artifacts", "*", "metadata.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed listing cookbooks in %q: %w", p, err)
+	}
+
+	index := make(memFS)
+	for _, m := range matches {
+		data, err := fs.ReadFile(fsys, m)
+		if err != nil {
+			return nil, fmt.Errorf("failed reading cookbook metadata: %w", err)
+		}
+
+		if err := index.add(m, data); err != nil {
+			return nil, fmt.Errorf("failed indexing %q: %w", p, err)
+		}
+	}
+
+	return index, nil
+}
+
+// Mount returns a filesystem serving fsys, where every cookbook path that is an artifact
+// is replaced by its index of cookbooks. It returns fsys itself if no artifacts are found.
+func Mount(fsys fs.FS, cookbookPaths []string) (fs.FS, error) {
+	m := &mountFS{FS: fsys, mounts: make(map[string]fs.FS)}
+	for _, p := range cookbookPaths {
+		if !Is(fsys, p) {
+			continue
+		}
+
+		index, err := Open(fsys, p)
+		if err != nil {
+			return nil, err
+		}
+		m.mounts[path.Clean(filepath.ToSlash(p))] = index
+	}
+
+	if len(m.mounts) == 0 {
+		return fsys, nil
+	}
+
+	return m, nil
+}
+
+// mountFS serves files from the filesystems mounted at some of its paths, and from
+// the embedded filesystem otherwise.
+type mountFS struct {
+	fs.FS
+	// mounts maps mount points to the filesystem mounted there.
+	mounts map[string]fs.FS
+}
+
+// Open opens the named file, from the filesystem mounted at any of its parent directories if there is one.
+// Names aren't required to be valid fs paths, as the embedded filesystem may accept absolute or
+// relative operating system paths, as chef.LocalFS does.
+func (m *mountFS) Open(name string) (fs.File, error) {
+	clean := path.Clean(filepath.ToSlash(name))
+	for point, fsys := range m.mounts {
+		if clean == point {
+			return fsys.Open(".")
+		}
+
+		if rest := strings.TrimPrefix(clean, strings.TrimSuffix(point, "/")+"/"); rest != clean {
+			return fsys.Open(rest)
+		}
+	}
+
+	return m.FS.Open(name)
+}
@@ -0,0 +1,120 @@
+package artifact
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	qt "github.com/frankban/quicktest"
+
+	"slack/whisk/chef"
+)
+
+// tarball returns a gzipped tarball holding the given files.
+func tarball(c *qt.C, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		c.Assert(tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}), qt.IsNil)
+		_, err := tw.Write([]byte(content))
+		c.Assert(err, qt.IsNil)
+	}
+	c.Assert(tw.Close(), qt.IsNil)
+	c.Assert(gz.Close(), qt.IsNil)
+
+	return buf.Bytes()
+}
+
+func TestMount(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+
+	fsys := fstest.MapFS{
+		"cookbooks/users/metadata.rb": {Data: []byte("name 'users'\n")},
+		"build/cookbooks.tgz": {Data: tarball(c, map[string]string{
+			"cookbooks/apt/metadata.json":   `{"name": "apt", "dependencies": {}}`,
+			"cookbooks/apt/metadata.rb":     "name 'apt'\n",
+			"cookbooks/nginx/metadata.json": `{"name": "nginx", "dependencies": {"apt": ">= 0.0.0"}}`,
+		})},
+		"export/Policyfile.lock.json":                        {Data: []byte(`{}`)},
+		"export/cookbook_artifacts/ssl-8f3a1b/metadata.json": {Data: []byte(`{"name": "ssl", "dependencies": {}}`)},
+	}
+
+	for p, expected := range map[string][]string{
+		"build/cookbooks.tgz": {"apt/metadata.json", "nginx/metadata.json"},
+		"export":              {"ssl/metadata.json"},
+	} {
+		c.Assert(Is(fsys, p), qt.IsTrue)
+
+		index, err := Open(fsys, p)
+		c.Assert(err, qt.IsNil)
+		c.Assert(fstest.TestFS(index, expected...), qt.IsNil)
+	}
+	c.Assert(Is(fsys, "cookbooks"), qt.IsFalse)
+
+	mounted, err := Mount(fsys, []string{"cookbooks", "build/cookbooks.tgz", "export"})
+	c.Assert(err, qt.IsNil)
+
+	for _, p := range []string{"cookbooks/users/metadata.rb", "build/cookbooks.tgz/apt/metadata.json", "export/ssl/metadata.json"} {
+		_, err := fs.Stat(mounted, p)
+		c.Assert(err, qt.IsNil, qt.Commentf("%s should exist", p))
+	}
+
+	data, err := fs.ReadFile(mounted, "build/cookbooks.tgz/nginx/metadata.json")
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(data), qt.Equals, `{"name": "nginx", "dependencies": {"apt": ">= 0.0.0"}}`)
+
+	_, err = fs.Stat(mounted, "build/cookbooks.tgz/apt/metadata.rb")
+	c.Assert(err, qt.ErrorIs, fs.ErrNotExist)
+}
+
+func TestMountOSPaths(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+
+	dir := t.TempDir()
+	c.Assert(os.WriteFile(filepath.Join(dir, "cookbooks.tgz"), tarball(c, map[string]string{
+		"cookbooks/apt/metadata.json": `{"name": "apt", "dependencies": {}}`,
+	}), 0o644), qt.IsNil)
+	c.Assert(os.MkdirAll(filepath.Join(dir, "roles"), 0o755), qt.IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dir, "roles", "web.json"), []byte(`{"name": "web"}`), 0o644), qt.IsNil)
+
+	wd, err := os.Getwd()
+	c.Assert(err, qt.IsNil)
+	rel, err := filepath.Rel(wd, dir)
+	c.Assert(err, qt.IsNil)
+	c.Assert(rel, qt.Matches, `\.\./.*`)
+
+	tests := []struct {
+		name string
+		root string
+	}{
+		{name: "absolute", root: dir},
+		{name: "relative to a parent directory", root: rel},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := qt.New(t)
+
+			mounted, err := Mount(chef.LocalFS, []string{filepath.Join(tt.root, "cookbooks.tgz")})
+			c.Assert(err, qt.IsNil)
+
+			data, err := fs.ReadFile(mounted, filepath.Join(tt.root, "cookbooks.tgz", "apt", "metadata.json"))
+			c.Assert(err, qt.IsNil)
+			c.Assert(string(data), qt.Equals, `{"name": "apt", "dependencies": {}}`)
+
+			data, err = fs.ReadFile(mounted, filepath.Join(tt.root, "roles", "web.json"))
+			c.Assert(err, qt.IsNil)
+			c.Assert(string(data), qt.Equals, `{"name": "web"}`)
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package artifact
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"slack/whisk/internal/memfs"
+)
+
+// memFS is an in-memory filesystem holding cookbooks' metadata.json files, keyed by cookbook name.
+type memFS map[string][]byte
+
+// add indexes the metadata.json found at p under the cookbook name it declares.
+func (m memFS) add(p string, data []byte) error {
+	var metadata struct {
+		Name string `json:"name"`
+	}
+
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return fmt.Errorf("failed decoding %q: %w", p, err)
+	}
+
+	if metadata.Name == "" {
+		return fmt.Errorf("cookbook name not found in %q", p)
+	}
+
+	if _, ok := m[metadata.Name]; !ok {
+		m[metadata.Name] = data
+	}
+
+	return nil
+}
+
+// Open opens the named file or directory.
+func (m memFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if name == "." {
+		entries := make([]fs.DirEntry, 0, len(m))
+		for cookbook := range m {
+			entries = append(entries, memfs.NewDirInfo(cookbook))
+		}
+
+		return memfs.NewDir(name, entries), nil
+	}
+
+	cookbook, file, _ := strings.Cut(name, "/")
+	data, ok := m[cookbook]
+	switch {
+	case !ok:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	case file == "":
+		return memfs.NewDir(name, []fs.DirEntry{memfs.NewInfo("metadata.json", 0o444, int64(len(data)))}), nil
+	case file == "metadata.json":
+		return memfs.NewFile(name, 0o444, data), nil
+	default:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+}
@@ -0,0 +1,123 @@
+package whisk
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+
+	"slack/whisk/chef"
+
+	"github.com/xlab/treeprint"
+)
+
+// Reasons why a Berksfile.lock and cookbooks metadata may disagree.
+const (
+	OnlyInMetadata      = "only in metadata"
+	OnlyInBerksfileLock = "only in Berksfile.lock"
+	MetadataNotFound    = "metadata not found"
+)
+
+// Discrepancy is a difference between the dependencies resolved in a Berksfile.lock
+// and the ones declared in cookbooks metadata.
+type Discrepancy struct {
+	// Cookbook is the name of the cookbook whose dependencies disagree.
+	Cookbook string `json:"cookbook"`
+	// Dependency is the dependency found on only one side, if any.
+	Dependency string `json:"dependency,omitempty"`
+	// Reason explains the discrepancy, see OnlyInMetadata, OnlyInBerksfileLock and MetadataNotFound.
+	Reason string `json:"reason"`
+}
+
+// WalkBerksfileLock loads the dependency graph resolved in a Berksfile.lock into memory, starting
+// from the Berksfile's direct dependencies, followed by any cookbook not reachable from them.
+func (h *Handler) WalkBerksfileLock(lock *chef.BerksfileLock, tree treeprint.Tree) {
+	roots := append([]string(nil), lock.Dependencies...)
+	for _, name := range sortLocked(lock.Graph) {
+		roots = append(roots, name)
+	}
+
+	for _, name := range roots {
+		if _, ok := h.graph[name]; !ok {
+			h.walkLocked(lock, name, tree.AddBranch(name))
+		}
+	}
+}
+
+// walkLocked recursively walks a locked cookbook's dependencies and loads them into
+// the graph adjency list.
+func (h *Handler) walkLocked(lock *chef.BerksfileLock, name string, tree treeprint.Tree) {
+	h.graph[name] = []string{}
+
+	cookbook, ok := lock.Graph[name]
+	if !ok {
+		return
+	}
+
+	for _, dep := range sortKeys(cookbook.Deps) {
+		h.graph[name] = append(h.graph[name], dep)
+
+		if _, ok := h.graph[dep]; !ok {
+			h.walkLocked(lock, dep, tree.AddBranch(dep))
+		}
+	}
+}
+
+// CheckBerksfileLock cross-checks the dependencies resolved in a Berksfile.lock against the ones
+// declared in the metadata of every locked cookbook available locally: cookbooks sourced from a
+// path: are read from it, while the rest are looked up in the cookbook paths. Cookbooks sourced from
+// git: are skipped, as are cookbooks from other sources, like the Supermarket, not found in the
+// cookbook paths, since their metadata only exists remotely.
+func (h *Handler) CheckBerksfileLock(lock *chef.BerksfileLock) ([]Discrepancy, error) {
+	var discrepancies []Discrepancy
+	for _, name := range sortLocked(lock.Graph) {
+		if _, ok := lock.Git[name]; ok {
+			continue
+		}
+
+		cookbook := chef.NewCookbook(h.fsys, h.cookbookPaths, name)
+		p, local := lock.Paths[name]
+		if local {
+			// the directory of a path: source doesn't need to be named after its cookbook.
+			dir := path.Join(lock.Dir, p)
+			cookbook = chef.NewCookbook(h.fsys, []string{path.Dir(dir)}, path.Base(dir))
+		}
+
+		if err := cookbook.LoadDeps(); err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				return nil, fmt.Errorf("unable to load %q dependencies: %w", name, err)
+			}
+
+			if local {
+				discrepancies = append(discrepancies, Discrepancy{Cookbook: name, Reason: MetadataNotFound})
+			}
+			continue
+		}
+
+		for _, dep := range sortKeys(cookbook.Deps) {
+			if _, ok := lock.Graph[name].Deps[dep]; !ok {
+				discrepancies = append(discrepancies, Discrepancy{Cookbook: name, Dependency: dep, Reason: OnlyInMetadata})
+			}
+		}
+
+		for _, dep := range sortKeys(lock.Graph[name].Deps) {
+			if _, ok := cookbook.Deps[dep]; !ok {
+				discrepancies = append(discrepancies, Discrepancy{Cookbook: name, Dependency: dep, Reason: OnlyInBerksfileLock})
+			}
+		}
+	}
+
+	return discrepancies, nil
+}
+
+// sortLocked sorts the locked cookbooks names alphabetically.
+func sortLocked(m map[string]*chef.LockedCookbook) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,97 @@
+package whisk
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"slack/whisk/chef"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/xlab/treeprint"
+)
+
+func TestHandlerBerksfileLock(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+
+	fsys := fstest.MapFS{
+		"cookbooks/apt/metadata.rb":   {Data: []byte("name 'apt'\n")},
+		"cookbooks/nginx/metadata.rb": {Data: []byte("name 'nginx'\ndepends 'apt'\ndepends 'ssl'\n")},
+	}
+
+	lock := &chef.BerksfileLock{
+		Dependencies: []string{"nginx"},
+		Graph: map[string]*chef.LockedCookbook{
+			"apt":      {Version: "7.4.0", Deps: map[string]string{"nginx": ">= 0.0.0"}},
+			"nginx":    {Version: "2.7.6", Deps: map[string]string{"apt": ">= 2.2"}},
+			"bluepill": {Version: "2.3.1", Deps: map[string]string{}},
+		},
+	}
+
+	h := NewHandler([]string{"cookbooks"}, "", WithFS(fsys))
+	h.WalkBerksfileLock(lock, treeprint.New())
+	c.Assert(h.FindCycles(), qt.IsNil)
+	c.Assert(h.Result().G, qt.DeepEquals, map[string][]string{
+		"apt":      {"nginx"},
+		"bluepill": {},
+		"nginx":    {"apt"},
+	})
+	c.Assert(h.Result().Cycles, qt.DeepEquals, [][]string{{"apt", "nginx", "apt"}})
+
+	discrepancies, err := h.CheckBerksfileLock(lock)
+	c.Assert(err, qt.IsNil)
+	c.Assert(discrepancies, qt.DeepEquals, []Discrepancy{
+		{Cookbook: "apt", Dependency: "nginx", Reason: OnlyInBerksfileLock},
+		{Cookbook: "nginx", Dependency: "ssl", Reason: OnlyInMetadata},
+	})
+}
+
+func TestHandlerCheckBerksfileLockSources(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+
+	fsys := fstest.MapFS{
+		"app/metadata.rb":            {Data: []byte("name 'app'\ndepends 'nginx'\ndepends 'ssl'\n")},
+		"app/vendor/web/metadata.rb": {Data: []byte("name 'nginx'\ndepends 'apt'\n")},
+		"cookbooks/apt/metadata.rb":  {Data: []byte("name 'apt'\n")},
+		"cookbooks/ssl/metadata.rb":  {Data: []byte("name 'ssl'\ndepends 'openssl'\n")},
+		"app/Berksfile.lock": {Data: []byte(`DEPENDENCIES
+  app
+    path: .
+    metadata: true
+  nginx
+    path: vendor/web
+  ssl
+    git: https://github.com/acme/ssl.git
+    revision: 8f3a1b2c
+  users
+    path: ../users
+
+GRAPH
+  app (1.0.0)
+    nginx (>= 0.0.0)
+    ssl (>= 0.0.0)
+  apt (7.4.0)
+    compat_resource (>= 0.0.0)
+  compat_resource (12.19.1)
+  nginx (2.7.6)
+    apt (>= 2.2)
+  ssl (3.0.0)
+  users (5.0.0)
+`)},
+	}
+
+	lock, err := chef.NewBerksfileLockFS(fsys, "app/Berksfile.lock")
+	c.Assert(err, qt.IsNil)
+
+	h := NewHandler([]string{"cookbooks"}, "", WithFS(fsys))
+	discrepancies, err := h.CheckBerksfileLock(lock)
+	c.Assert(err, qt.IsNil)
+
+	// ssl is skipped for coming from git, and compat_resource for coming from the Supermarket, while
+	// ssl's copy in the cookbook paths would disagree.
+	c.Assert(discrepancies, qt.DeepEquals, []Discrepancy{
+		{Cookbook: "apt", Dependency: "compat_resource", Reason: OnlyInBerksfileLock},
+		{Cookbook: "users", Reason: MetadataNotFound},
+	})
+}
@@ -0,0 +1,131 @@
+package chef
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// BerksfileLock is the dependency graph resolved by Berkshelf, as stored in a Berksfile.lock.
+type BerksfileLock struct {
+	// Dependencies are the cookbooks the Berksfile depends on directly.
+	Dependencies []string
+	// Graph maps every resolved cookbook to its locked version and dependencies.
+	Graph map[string]*LockedCookbook
+	// Paths maps the dependencies sourced from a local directory, with path:, to it, relative to Dir.
+	Paths map[string]string
+	// Git maps the dependencies sourced from a git repository, with git:, to the repository's URL.
+	Git map[string]string
+	// Dir is the directory the Berksfile.lock is stored in, when loaded from a filesystem.
+	Dir string
+}
+
+// LockedCookbook is a cookbook as resolved by Berkshelf.
+type LockedCookbook struct {
+	// Version is the version the cookbook is locked to.
+	Version string
+	// Deps maps the cookbook's dependencies to their version constraints.
+	Deps map[string]string
+}
+
+// NewBerksfileLockFS opens and decodes a Berksfile.lock stored in fsys.
+func NewBerksfileLockFS(fsys fs.FS, p string) (*BerksfileLock, error) {
+	f, err := fsys.Open(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed opening Berksfile.lock: %w", err)
+	}
+	defer f.Close()
+
+	lock, err := ParseBerksfileLock(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed decoding %q: %w", p, err)
+	}
+	lock.Dir = path.Dir(p)
+
+	return lock, nil
+}
+
+// ParseBerksfileLock decodes the DEPENDENCIES and GRAPH sections of a Berksfile.lock, along with the
+// path: and git: sources of dependencies, which look like:
+//
+//	DEPENDENCIES
+//	  nginx (~> 2.7)
+//	    path: cookbooks/nginx
+//	  ssl
+//	    git: https://github.com/acme/ssl.git
+//	    revision: 8f3a1b2c
+//
+//	GRAPH
+//	  apt (7.4.0)
+//	  nginx (2.7.6)
+//	    apt (>= 2.2)
+func ParseBerksfileLock(r io.Reader) (*BerksfileLock, error) {
+	lock := &BerksfileLock{
+		Graph: make(map[string]*LockedCookbook),
+		Paths: make(map[string]string),
+		Git:   make(map[string]string),
+	}
+
+	var (
+		section    string
+		current    *LockedCookbook
+		dependency string
+		lineNo     int
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		lineNo++
+
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		switch {
+		case indent == 0:
+			section = line
+			current = nil
+			continue
+		case section == "DEPENDENCIES" && indent == 2:
+			dependency, _ = splitEntry(line)
+			lock.Dependencies = append(lock.Dependencies, dependency)
+		case section == "DEPENDENCIES" && indent == 4:
+			key, value, _ := strings.Cut(strings.TrimSpace(line), ":")
+			switch key {
+			case "path":
+				lock.Paths[dependency] = strings.TrimSpace(value)
+			case "git":
+				lock.Git[dependency] = strings.TrimSpace(value)
+			}
+		case section == "GRAPH" && indent == 2:
+			name, version := splitEntry(line)
+			current = &LockedCookbook{Version: version, Deps: make(map[string]string)}
+			lock.Graph[name] = current
+		case section == "GRAPH" && indent == 4:
+			if current == nil {
+				return nil, fmt.Errorf("line %d: dependency without cookbook: %q", lineNo, line)
+			}
+
+			name, constraint := splitEntry(line)
+			current.Deps[name] = constraint
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading Berksfile.lock: %w", err)
+	}
+
+	return lock, nil
+}
+
+// splitEntry splits lines like "nginx (~> 2.7)" into the cookbook's name and the text in parentheses.
+func splitEntry(line string) (string, string) {
+	name, rest, _ := strings.Cut(strings.TrimSpace(line), " ")
+
+	return name, strings.Trim(strings.TrimSpace(rest), "()")
+}
@@ -0,0 +1,45 @@
+package chef
+
+import (
+	"strings"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestParseBerksfileLock(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+
+	lock, err := ParseBerksfileLock(strings.NewReader(`DEPENDENCIES
+  apt
+  nginx (~> 2.7)
+    path: cookbooks/nginx
+    metadata: true
+  ssl
+    git: https://github.com/acme/ssl.git
+    revision: 8f3a1b2c
+
+GRAPH
+  apt (7.4.0)
+  bluepill (2.3.1)
+    rsyslog (>= 0.0.0)
+  nginx (2.7.6)
+    apt (>= 2.2)
+    bluepill (>= 0.0.0)
+  rsyslog (1.12.2)
+`))
+
+	c.Assert(err, qt.IsNil)
+	c.Assert(lock, qt.DeepEquals, &BerksfileLock{
+		Dependencies: []string{"apt", "nginx", "ssl"},
+		Graph: map[string]*LockedCookbook{
+			"apt":      {Version: "7.4.0", Deps: map[string]string{}},
+			"bluepill": {Version: "2.3.1", Deps: map[string]string{"rsyslog": ">= 0.0.0"}},
+			"nginx":    {Version: "2.7.6", Deps: map[string]string{"apt": ">= 2.2", "bluepill": ">= 0.0.0"}},
+			"rsyslog":  {Version: "1.12.2", Deps: map[string]string{}},
+		},
+		Paths: map[string]string{"nginx": "cookbooks/nginx"},
+		Git:   map[string]string{"ssl": "https://github.com/acme/ssl.git"},
+	})
+}
@@ -5,8 +5,9 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"path/filepath"
+	"io/fs"
+	"path"
+	"strconv"
 	"strings"
 )
 
@@ -14,13 +15,26 @@ import (
 type Role struct {
 	// Name is the name given to the role
 	Name string `json:"name"`
+	// Description describes what the role is for.
+	Description string `json:"description"`
 	// RunList is the list of roles and/or recipes Chef will run in order.
 	RunList []string `json:"run_list"`
+	// JSONClass is the Ruby class the role is decoded into by Chef, which must be Chef::Role.
+	JSONClass string `json:"json_class"`
+	// ChefType is the type of Chef object, which must be role.
+	ChefType string `json:"chef_type"`
+	// Path is the path of the file the role was decoded from.
+	Path string `json:"-"`
 }
 
-// NewRole opens and decodes a role file.
+// NewRole opens and decodes a role file from the local filesystem.
 func NewRole(path string) (*Role, error) {
-	data, err := ioutil.ReadFile(path)
+	return NewRoleFS(LocalFS, path)
+}
+
+// NewRoleFS opens and decodes a role file stored in fsys.
+func NewRoleFS(fsys fs.FS, path string) (*Role, error) {
+	data, err := fs.ReadFile(fsys, path)
 	if err != nil {
 		return nil, fmt.Errorf("failed opening role file: %w", err)
 	}
@@ -29,24 +43,75 @@ func NewRole(path string) (*Role, error) {
 	if err := json.Unmarshal(data, role); err != nil {
 		return nil, fmt.Errorf("failed decoding role file %q: %w", path, err)
 	}
+	role.Path = path
 
 	return role, nil
 }
 
+// Node is a Chef node, as exported by `knife node show -F json`.
+type Node struct {
+	// Name is the node's name.
+	Name string `json:"name"`
+	// Environment is the Chef environment the node belongs to.
+	Environment string `json:"chef_environment"`
+	// RunList is the node's own list of roles and/or recipes Chef will run in order.
+	RunList []string `json:"run_list"`
+}
+
+// NewNodeFS opens and decodes a node file stored in fsys.
+func NewNodeFS(fsys fs.FS, path string) (*Node, error) {
+	data, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed opening node file: %w", err)
+	}
+
+	node := new(Node)
+	if err := json.Unmarshal(data, node); err != nil {
+		return nil, fmt.Errorf("failed decoding node file %q: %w", path, err)
+	}
+
+	return node, nil
+}
+
 // Cookbook is a Chef Cookbook.
 type Cookbook struct {
+	// FS is the filesystem cookbook paths are looked up in.
+	FS            fs.FS
 	CookbookPaths []string
 	Name          string            `json:"name"`
+	Version       string            `json:"version"`
 	Deps          map[string]string `json:"dependencies"`
+	// Path is the directory the cookbook was found in, once its dependencies are loaded.
+	Path string `json:"-"`
+	// Metadata is the path of the metadata file dependencies were loaded from.
+	Metadata string `json:"-"`
+	// Lines maps every dependency to the line of the metadata file declaring it, starting at 1.
+	Lines map[string]int `json:"-"`
 }
 
-// LoadDeps loads the cookbook's dependencies, trying first from its metadata.rb,
+// NewCookbook initializes a cookbook to be looked up in the given cookbook paths of fsys,
+// in order, when loading its dependencies.
+func NewCookbook(fsys fs.FS, cookbookPaths []string, name string) *Cookbook {
+	return &Cookbook{
+		FS:            fsys,
+		CookbookPaths: cookbookPaths,
+		Name:          name,
+		Deps:          make(map[string]string),
+		Lines:         make(map[string]int),
+	}
+}
+
+// LoadDeps loads the cookbook's version and dependencies, along with their version constraints, trying first from its metadata.rb,
 // if it exists, or its metadata.json file, otherwise.
 func (c *Cookbook) LoadDeps() error {
 	if c.Name == "" {
 		return fmt.Errorf("cookbook name can't be empty")
 	}
 
+	if c.FS == nil {
+		c.FS = LocalFS
+	}
+
 	if err := c.tryRuby(); err == nil {
 		return nil
 	}
@@ -56,14 +121,16 @@ func (c *Cookbook) LoadDeps() error {
 
 // tryJSON reads the cookbook's dependencies for a metadata.json file.
 func (c *Cookbook) tryJSON() error {
-	metadataPath := filepath.Join(c.Name, "metadata.json")
+	metadataPath := path.Join(c.Name, "metadata.json")
 	var (
 		metadata []byte
 		err      error
 	)
 	for _, p := range c.CookbookPaths {
-		metadata, err = ioutil.ReadFile(filepath.Join(p, metadataPath))
+		metadata, err = fs.ReadFile(c.FS, path.Join(p, metadataPath))
 		if err == nil {
+			c.Path = path.Join(p, c.Name)
+			c.Metadata = path.Join(p, metadataPath)
 			break
 		}
 	}
@@ -76,19 +143,36 @@ func (c *Cookbook) tryJSON() error {
 		return fmt.Errorf("failed decoding %q: %w", metadataPath, err)
 	}
 
+	// dependencies are looked up past the dependencies key, so lines of other keys
+	// mentioning the same cookbook, like recommendations, don't get in the way.
+	start := bytes.Index(metadata, []byte(`"dependencies"`))
+	for dep := range c.Deps {
+		if start < 0 {
+			break
+		}
+
+		i := bytes.Index(metadata[start:], []byte(strconv.Quote(dep)))
+		if i < 0 {
+			continue
+		}
+		c.Lines[dep] = bytes.Count(metadata[:start+i], []byte("\n")) + 1
+	}
+
 	return nil
 }
 
 // tryRuby reads the cookbook's dependencies from a metadata.rb file.
 func (c *Cookbook) tryRuby() error {
-	metadataPath := filepath.Join(c.Name, "metadata.rb")
+	metadataPath := path.Join(c.Name, "metadata.rb")
 	var (
 		metadata []byte
 		err      error
 	)
 	for _, p := range c.CookbookPaths {
-		metadata, err = ioutil.ReadFile(filepath.Join(p, metadataPath))
+		metadata, err = fs.ReadFile(c.FS, path.Join(p, metadataPath))
 		if err == nil {
+			c.Path = path.Join(p, c.Name)
+			c.Metadata = path.Join(p, metadataPath)
 			break
 		}
 	}
@@ -99,19 +183,52 @@ func (c *Cookbook) tryRuby() error {
 
 	f := bytes.NewReader(metadata)
 	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
+	for n := 1; scanner.Scan(); n++ {
 		line := scanner.Text()
 
 		if len(line) == 0 {
 			continue
 		}
 
-		if strings.HasPrefix(line, "depends") {
-			parts := strings.Fields(line)
-			cookbook := strings.Trim(parts[1], `"',`)
-			c.Deps[cookbook] = "" // TODO(c4milo): track version if it makes sense.
+		switch {
+		case strings.HasPrefix(line, "depends"):
+			args := rubyArgs(strings.TrimPrefix(line, "depends"))
+			if len(args) == 0 {
+				continue
+			}
+
+			cookbook, constraint := args[0], ""
+			if len(args) > 1 {
+				constraint = args[1]
+			}
+			c.Deps[cookbook] = constraint
+			c.Lines[cookbook] = n
+		case strings.HasPrefix(line, "version"):
+			if args := rubyArgs(strings.TrimPrefix(line, "version")); len(args) > 0 {
+				c.Version = args[0]
+			}
 		}
 	}
 
 	return nil
 }
+
+// rubyArgs returns the string arguments of a metadata.rb method call, such as `'apt', '>= 1.0'`,
+// with or without parentheses, ignoring trailing comments.
+func rubyArgs(call string) []string {
+	if i := strings.Index(call, "#"); i >= 0 {
+		call = call[:i]
+	}
+	call = strings.Trim(strings.TrimSpace(call), "()")
+
+	var args []string
+	for _, arg := range strings.Split(call, ",") {
+		arg = strings.Trim(strings.TrimSpace(arg), `"'`)
+		if arg == "" {
+			continue
+		}
+		args = append(args, arg)
+	}
+
+	return args
+}
@@ -0,0 +1,99 @@
+package chef
+
+import (
+	"testing"
+	"testing/fstest"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestNewRoleFS(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+
+	fsys := fstest.MapFS{
+		"roles/web.json": {Data: []byte(`{"name": "web-server", "description": "Serves the web", "run_list": ["role[base]", "recipe[nginx]"]}`)},
+	}
+
+	role, err := NewRoleFS(fsys, "roles/web.json")
+	c.Assert(err, qt.IsNil)
+	c.Assert(role, qt.DeepEquals, &Role{
+		Name:        "web-server",
+		Description: "Serves the web",
+		RunList:     []string{"role[base]", "recipe[nginx]"},
+		Path:        "roles/web.json",
+	})
+
+	_, err = NewRoleFS(fsys, "roles/missing.json")
+	c.Assert(err, qt.IsNotNil)
+}
+
+func TestNewNodeFS(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+
+	fsys := fstest.MapFS{
+		"nodes/web-1.json": {Data: []byte(`{"name": "web-1", "chef_environment": "prod", "run_list": ["role[web]"], "normal": {}}`)},
+	}
+
+	node, err := NewNodeFS(fsys, "nodes/web-1.json")
+	c.Assert(err, qt.IsNil)
+	c.Assert(node, qt.DeepEquals, &Node{
+		Name:        "web-1",
+		Environment: "prod",
+		RunList:     []string{"role[web]"},
+	})
+
+	_, err = NewNodeFS(fsys, "nodes/missing.json")
+	c.Assert(err, qt.IsNotNil)
+}
+
+func TestCookbookLoadDeps(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"site-cookbooks/nginx/metadata.rb": {Data: []byte("name 'nginx'\ndepends 'apt'\ndepends \"openssl\", '>= 1.0'\nversion '2.1.0' # bumped\ndepends('ssl', '~> 3.0')\n")},
+		"cookbooks/nginx/metadata.rb":      {Data: []byte("name 'nginx'\n")},
+		"cookbooks/apt/metadata.json":      {Data: []byte("{\n  \"name\": \"apt\",\n  \"version\": \"7.4.0\",\n  \"recommendations\": {\"gpg\": \"\"},\n  \"dependencies\": {\n    \"gpg\": \">= 0.0.0\"\n  }\n}\n")},
+	}
+
+	tests := []struct {
+		name     string
+		cookbook string
+		expected map[string]string
+		version  string
+		metadata string
+		lines    map[string]int
+	}{
+		{
+			"it should load dependencies from metadata.rb in the first cookbook path having it",
+			"nginx",
+			map[string]string{"apt": "", "openssl": ">= 1.0", "ssl": "~> 3.0"},
+			"2.1.0",
+			"site-cookbooks/nginx/metadata.rb",
+			map[string]int{"apt": 2, "openssl": 3, "ssl": 5},
+		},
+		{
+			"it should load dependencies from metadata.json",
+			"apt",
+			map[string]string{"gpg": ">= 0.0.0"},
+			"7.4.0",
+			"cookbooks/apt/metadata.json",
+			map[string]int{"gpg": 6},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := qt.New(t)
+			cookbook := NewCookbook(fsys, []string{"site-cookbooks", "cookbooks"}, tt.cookbook)
+			c.Assert(cookbook.LoadDeps(), qt.IsNil)
+			c.Assert(cookbook.Deps, qt.DeepEquals, tt.expected)
+			c.Assert(cookbook.Version, qt.Equals, tt.version)
+			c.Assert(cookbook.Metadata, qt.Equals, tt.metadata)
+			c.Assert(cookbook.Lines, qt.DeepEquals, tt.lines)
+		})
+	}
+}
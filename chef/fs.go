@@ -0,0 +1,34 @@
+package chef
+
+import (
+	"io/fs"
+	"os"
+)
+
+// LocalFS is the local filesystem. Unlike os.DirFS, it isn't rooted at any directory and
+// accepts absolute and relative paths the same way the operating system does, so paths
+// given by users can be used as is.
+var LocalFS fs.FS = localFS{}
+
+// localFS implements fs.FS on top of the os package.
+type localFS struct{}
+
+// Open opens the named file.
+func (localFS) Open(name string) (fs.File, error) {
+	return os.Open(name)
+}
+
+// ReadFile reads the named file and returns its contents.
+func (localFS) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+// ReadDir reads the named directory and returns its entries sorted by filename.
+func (localFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+// Stat returns information about the named file.
+func (localFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
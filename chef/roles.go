@@ -0,0 +1,84 @@
+package chef
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// FindRoles recursively walks dir in fsys and returns the paths of the role files found, in lexical
+// order. include and exclude are glob patterns, as supported by path.Match, matched against paths
+// relative to dir or, for patterns without a slash, against base names. When include patterns are
+// given, only role files matching any of them are returned. Files and directories matching any
+// exclude pattern are skipped, as well as hidden directories.
+func FindRoles(fsys fs.FS, dir string, include, exclude []string) ([]string, error) {
+	for _, patterns := range [][]string{include, exclude} {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid role pattern %q: %w", pattern, err)
+			}
+		}
+	}
+
+	var roles []string
+	fn := func(p string, d fs.DirEntry, err error) error {
+		// If there was any error stat()ing path, return it.
+		if err != nil {
+			return err
+		}
+
+		// Do not attempt to match the roles base dir.
+		if p == dir {
+			return nil
+		}
+
+		rel := strings.TrimPrefix(p, dir+"/")
+		if dir == "." {
+			rel = p
+		}
+
+		if d.IsDir() {
+			if strings.HasPrefix(d.Name(), ".") || matchAny(exclude, rel) {
+				return fs.SkipDir
+			}
+
+			return nil
+		}
+
+		if !strings.HasSuffix(p, ".json") || matchAny(exclude, rel) {
+			return nil
+		}
+
+		if len(include) > 0 && !matchAny(include, rel) {
+			return nil
+		}
+
+		roles = append(roles, p)
+
+		return nil
+	}
+
+	if err := fs.WalkDir(fsys, dir, fn); err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+// matchAny returns whether the relative path matches any of the patterns.
+func matchAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		name := rel
+		if !strings.Contains(pattern, "/") {
+			name = path.Base(rel)
+		}
+
+		// patterns were validated up front, so errors can't happen here.
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
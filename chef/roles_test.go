@@ -0,0 +1,62 @@
+package chef
+
+import (
+	"testing"
+	"testing/fstest"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestFindRoles(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"roles/base.json":        {Data: []byte(`{}`)},
+		"roles/README.md":        {Data: []byte(``)},
+		"roles/prod/web.json":    {Data: []byte(`{}`)},
+		"roles/prod/db.json":     {Data: []byte(`{}`)},
+		"roles/team-x/app.json":  {Data: []byte(`{}`)},
+		"roles/.archive/ol.json": {Data: []byte(`{}`)},
+	}
+
+	tests := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		expected []string
+	}{
+		{
+			"it should find roles in subdirectories",
+			nil,
+			nil,
+			[]string{"roles/base.json", "roles/prod/db.json", "roles/prod/web.json", "roles/team-x/app.json"},
+		},
+		{
+			"it should only return roles matching include patterns",
+			[]string{"prod/*.json"},
+			nil,
+			[]string{"roles/prod/db.json", "roles/prod/web.json"},
+		},
+		{
+			"it should skip roles and directories matching exclude patterns",
+			nil,
+			[]string{"team-*", "db.json"},
+			[]string{"roles/base.json", "roles/prod/web.json"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := qt.New(t)
+
+			roles, err := FindRoles(fsys, "roles", tt.include, tt.exclude)
+			c.Assert(err, qt.IsNil)
+			c.Assert(roles, qt.DeepEquals, tt.expected)
+		})
+	}
+
+	_, err := FindRoles(fsys, "roles", []string{"[invalid"}, nil)
+	qt.Assert(t, err, qt.IsNotNil)
+}
@@ -0,0 +1,102 @@
+package chef
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ItemType is the type of a run list item, either a role or a recipe.
+type ItemType string
+
+const (
+	// RoleItem is a role[...] run list item.
+	RoleItem ItemType = "role"
+	// RecipeItem is a recipe run list item, as in recipe[...] or its bare form.
+	RecipeItem ItemType = "recipe"
+)
+
+// DefaultRecipe is the recipe run when a recipe item names a cookbook only.
+const DefaultRecipe = "default"
+
+var (
+	// qualifiedRecipe matches recipe[cookbook], recipe[cookbook::recipe] and their @version pinned forms.
+	qualifiedRecipe = regexp.MustCompile(`^recipe\[([^\]@]+)(?:@([0-9]+(?:\.[0-9]+){1,2}))?\]$`)
+	// qualifiedRole matches role[name].
+	qualifiedRole = regexp.MustCompile(`^role\[([^\]]+)\]$`)
+	// unqualifiedRecipe matches bare cookbook and cookbook::recipe entries and their @version pinned forms.
+	unqualifiedRecipe = regexp.MustCompile(`^([^\[\]@]+)(?:@([0-9]+(?:\.[0-9]+){1,2}))?$`)
+)
+
+// RunListItem is a parsed run list entry.
+type RunListItem struct {
+	// Type is whether the item is a role or a recipe.
+	Type ItemType `json:"type"`
+	// Name is the role name for roles, or the cookbook name for recipes.
+	Name string `json:"name"`
+	// Recipe is the recipe name within the cookbook, "default" when not given. Empty for roles.
+	Recipe string `json:"recipe,omitempty"`
+	// Version is the cookbook version the recipe is pinned to, if any.
+	Version string `json:"version,omitempty"`
+}
+
+// ParseRunListItem parses a run list entry in any of the syntaxes Chef accepts: role[name],
+// recipe[cookbook], recipe[cookbook::recipe], recipe[cookbook@1.2.3], and the bare cookbook and
+// cookbook::recipe forms, optionally version pinned as well.
+func ParseRunListItem(entry string) (RunListItem, error) {
+	if m := qualifiedRole.FindStringSubmatch(entry); m != nil {
+		return RunListItem{Type: RoleItem, Name: m[1]}, nil
+	}
+
+	m := qualifiedRecipe.FindStringSubmatch(entry)
+	if m == nil {
+		m = unqualifiedRecipe.FindStringSubmatch(entry)
+	}
+
+	if m == nil {
+		return RunListItem{}, fmt.Errorf("invalid run list item %q", entry)
+	}
+
+	cookbook, recipe, _ := strings.Cut(m[1], "::")
+	if cookbook == "" {
+		return RunListItem{}, fmt.Errorf("invalid run list item %q: missing cookbook name", entry)
+	}
+
+	if recipe == "" {
+		recipe = DefaultRecipe
+	}
+
+	return RunListItem{Type: RecipeItem, Name: cookbook, Recipe: recipe, Version: m[2]}, nil
+}
+
+// ParseRunList parses every entry of a run list.
+func ParseRunList(runList []string) ([]RunListItem, error) {
+	items := make([]RunListItem, 0, len(runList))
+	for _, entry := range runList {
+		item, err := ParseRunListItem(entry)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// QualifiedRecipe returns the fully qualified recipe name, as in cookbook::recipe.
+func (i RunListItem) QualifiedRecipe() string {
+	return fmt.Sprintf("%s::%s", i.Name, i.Recipe)
+}
+
+// String returns the item in Chef's canonical run list syntax.
+func (i RunListItem) String() string {
+	if i.Type == RoleItem {
+		return fmt.Sprintf("role[%s]", i.Name)
+	}
+
+	if i.Version != "" {
+		return fmt.Sprintf("recipe[%s@%s]", i.QualifiedRecipe(), i.Version)
+	}
+
+	return fmt.Sprintf("recipe[%s]", i.QualifiedRecipe())
+}
@@ -0,0 +1,48 @@
+package chef
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestParseRunListItem(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		entry    string
+		expected RunListItem
+		str      string
+		err      bool
+	}{
+		{"it should parse roles", "role[web]", RunListItem{Type: RoleItem, Name: "web"}, "role[web]", false},
+		{"it should default the recipe name", "recipe[nginx]", RunListItem{Type: RecipeItem, Name: "nginx", Recipe: "default"}, "recipe[nginx::default]", false},
+		{"it should parse qualified recipes", "recipe[nginx::source]", RunListItem{Type: RecipeItem, Name: "nginx", Recipe: "source"}, "recipe[nginx::source]", false},
+		{"it should keep version pins", "recipe[nginx::source@1.2.3]", RunListItem{Type: RecipeItem, Name: "nginx", Recipe: "source", Version: "1.2.3"}, "recipe[nginx::source@1.2.3]", false},
+		{"it should parse bare cookbooks", "nginx", RunListItem{Type: RecipeItem, Name: "nginx", Recipe: "default"}, "recipe[nginx::default]", false},
+		{"it should parse bare recipes", "nginx::source", RunListItem{Type: RecipeItem, Name: "nginx", Recipe: "source"}, "recipe[nginx::source]", false},
+		{"it should parse pinned bare recipes", "nginx@2.0", RunListItem{Type: RecipeItem, Name: "nginx", Recipe: "default", Version: "2.0"}, "recipe[nginx::default@2.0]", false},
+		{"it should reject unknown item types", "environment[prod]", RunListItem{}, "", true},
+		{"it should reject invalid versions", "recipe[nginx@latest]", RunListItem{}, "", true},
+		{"it should reject missing cookbook names", "recipe[::source]", RunListItem{}, "", true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := qt.New(t)
+
+			item, err := ParseRunListItem(tt.entry)
+			if tt.err {
+				c.Assert(err, qt.IsNotNil)
+				return
+			}
+
+			c.Assert(err, qt.IsNil)
+			c.Assert(item, qt.DeepEquals, tt.expected)
+			c.Assert(item.String(), qt.Equals, tt.str)
+		})
+	}
+}
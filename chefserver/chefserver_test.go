@@ -0,0 +1,68 @@
+package chefserver_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/fs"
+	"testing"
+
+	"slack/whisk/chef"
+	"slack/whisk/chefserver"
+	"slack/whisk/chefserver/chefservertest"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestFS(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+
+	srv, err := chefservertest.NewServer()
+	c.Assert(err, qt.IsNil)
+	defer srv.Close()
+
+	srv.Roles["web"] = `{"name": "web", "json_class": "Chef::Role", "chef_type": "role", "run_list": ["recipe[nginx]"]}`
+	srv.Cookbooks["nginx"] = `{"name": "nginx", "version": "2.7.6", "dependencies": {"apt": ">= 2.2"}}`
+	srv.Cookbooks["apt"] = `{"name": "apt", "version": "7.4.0", "dependencies": {}}`
+
+	client, err := chefserver.NewClient(srv.OrganizationURL(), chefservertest.ClientName, srv.KeyPEM())
+	c.Assert(err, qt.IsNil)
+	fsys := chefserver.NewFS(client)
+
+	roles, err := fs.ReadDir(fsys, "roles")
+	c.Assert(err, qt.IsNil)
+	c.Assert(roles, qt.HasLen, 1)
+	c.Assert(roles[0].Name(), qt.Equals, "web.json")
+
+	role, err := chef.NewRoleFS(fsys, "roles/web.json")
+	c.Assert(err, qt.IsNil)
+	c.Assert(role.RunList, qt.DeepEquals, []string{"recipe[nginx]"})
+
+	cookbook := chef.NewCookbook(fsys, []string{"cookbooks"}, "nginx")
+	c.Assert(cookbook.LoadDeps(), qt.IsNil)
+	c.Assert(cookbook.Deps, qt.DeepEquals, map[string]string{"apt": ">= 2.2"})
+
+	_, err = fs.Stat(fsys, "cookbooks/missing/metadata.json")
+	c.Assert(err, qt.ErrorIs, fs.ErrNotExist)
+}
+
+func TestClientUnauthorized(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+
+	srv, err := chefservertest.NewServer()
+	c.Assert(err, qt.IsNil)
+	defer srv.Close()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	c.Assert(err, qt.IsNil)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	client, err := chefserver.NewClient(srv.OrganizationURL(), chefservertest.ClientName, keyPEM)
+	c.Assert(err, qt.IsNil)
+
+	var roles map[string]string
+	c.Assert(client.Get("roles", &roles), qt.ErrorIs, fs.ErrPermission)
+}
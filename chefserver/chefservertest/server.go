@@ -0,0 +1,169 @@
+// Package chefservertest provides a fake Chef Server for testing, serving roles, environments
+// and cookbooks from memory and verifying requests are signed like the real Chef Server does.
+package chefservertest
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"slack/whisk/chefserver"
+)
+
+// Organization is the name of the organization served by the fake Chef Server.
+const Organization = "whisk"
+
+// ClientName is the name of the only client allowed by the fake Chef Server.
+const ClientName = "whisk-test"
+
+// Server is a fake Chef Server.
+type Server struct {
+	*httptest.Server
+	// Key is the private key of ClientName, see KeyPEM.
+	Key *rsa.PrivateKey
+	// Roles maps role names to their JSON representation.
+	Roles map[string]string
+	// Environments maps environment names to their JSON representation.
+	Environments map[string]string
+	// Cookbooks maps cookbook names to the JSON representation of their latest version's metadata.
+	Cookbooks map[string]string
+}
+
+// NewServer starts a fake Chef Server with a freshly generated client key. Callers must call Close when done.
+func NewServer() (*Server, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("failed generating client key: %w", err)
+	}
+
+	s := &Server{
+		Key:          key,
+		Roles:        make(map[string]string),
+		Environments: make(map[string]string),
+		Cookbooks:    make(map[string]string),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+
+	return s, nil
+}
+
+// OrganizationURL returns the URL of the organization served, to be used as the Chef Server URL.
+func (s *Server) OrganizationURL() string {
+	return s.URL + "/organizations/" + Organization
+}
+
+// KeyPEM returns the PEM encoded private key of ClientName.
+func (s *Server) KeyPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(s.Key)})
+}
+
+// serve authenticates and routes requests.
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	if err := s.authenticate(r); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error": [%q]}`, err.Error()), http.StatusUnauthorized)
+		return
+	}
+
+	prefix := "/organizations/" + Organization + "/"
+	if r.Method != http.MethodGet || !strings.HasPrefix(r.URL.Path, prefix) {
+		http.NotFound(w, r)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, prefix), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "roles":
+		s.list(w, "roles", s.Roles)
+	case len(parts) == 1 && parts[0] == "environments":
+		s.list(w, "environments", s.Environments)
+	case len(parts) == 1 && parts[0] == "cookbooks":
+		s.list(w, "cookbooks", s.Cookbooks)
+	case len(parts) == 2 && parts[0] == "roles":
+		s.object(w, r, s.Roles[parts[1]])
+	case len(parts) == 2 && parts[0] == "environments":
+		s.object(w, r, s.Environments[parts[1]])
+	case len(parts) == 3 && parts[0] == "cookbooks" && parts[2] == "_latest":
+		metadata, ok := s.Cookbooks[parts[1]]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		s.object(w, r, fmt.Sprintf(`{"cookbook_name": %q, "metadata": %s}`, parts[1], metadata))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// list writes the names of a collection's objects, along with their URL.
+func (s *Server) list(w http.ResponseWriter, collection string, objects map[string]string) {
+	out := make(map[string]string, len(objects))
+	for name := range objects {
+		out[name] = fmt.Sprintf("%s/%s/%s", s.OrganizationURL(), collection, name)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out) //nolint:errcheck // nothing to do if the client goes away.
+}
+
+// object writes an object's JSON representation, or a 404 response if it's empty.
+func (s *Server) object(w http.ResponseWriter, r *http.Request, data string) {
+	if data == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprint(w, data)
+}
+
+// authenticate verifies the request was signed by ClientName using the signing protocol version 1.3.
+func (s *Server) authenticate(r *http.Request) error {
+	if r.Header.Get("X-Ops-Sign") != "algorithm=sha256;version=1.3" {
+		return fmt.Errorf("unsupported signing protocol %q", r.Header.Get("X-Ops-Sign"))
+	}
+
+	if r.Header.Get("X-Ops-UserId") != ClientName {
+		return fmt.Errorf("unknown client %q", r.Header.Get("X-Ops-UserId"))
+	}
+
+	timestamp, err := time.Parse(time.RFC3339, r.Header.Get("X-Ops-Timestamp"))
+	if err != nil || time.Since(timestamp).Abs() > 15*time.Minute {
+		return fmt.Errorf("invalid timestamp %q", r.Header.Get("X-Ops-Timestamp"))
+	}
+
+	emptyBody := sha256.Sum256(nil)
+	if r.Header.Get("X-Ops-Content-Hash") != base64.StdEncoding.EncodeToString(emptyBody[:]) {
+		return fmt.Errorf("invalid content hash %q", r.Header.Get("X-Ops-Content-Hash"))
+	}
+
+	var encoded strings.Builder
+	for i := 1; r.Header.Get(fmt.Sprintf("X-Ops-Authorization-%d", i)) != ""; i++ {
+		encoded.WriteString(r.Header.Get(fmt.Sprintf("X-Ops-Authorization-%d", i)))
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(encoded.String())
+	if err != nil {
+		return fmt.Errorf("invalid signature encoding: %w", err)
+	}
+
+	canonical := chefserver.CanonicalRequest(r.Method, r.URL.Path, r.Header.Get("X-Ops-Content-Hash"),
+		r.Header.Get("X-Ops-Timestamp"), r.Header.Get("X-Ops-UserId"))
+	digest := sha256.Sum256([]byte(canonical))
+
+	if err := rsa.VerifyPKCS1v15(&s.Key.PublicKey, crypto.SHA256, digest[:], sig); err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,190 @@
+// Package chefserver reads roles and cookbooks metadata straight from the Chef Server REST API,
+// so what is actually uploaded can be analyzed rather than what is in the chef-repo.
+package chefserver
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// Client is a Chef Server API client authenticating requests with the Chef Server's
+// signing protocol version 1.3: https://docs.chef.io/server/api_chef_server/#authentication-headers
+type Client struct {
+	// BaseURL is the Chef Server URL, including the organization, like https://chef.example.com/organizations/acme.
+	BaseURL *url.URL
+	// ClientName is the name of the client or user signing requests.
+	ClientName string
+	// Key is the client's private key.
+	Key *rsa.PrivateKey
+	// HTTP is the HTTP client used to send requests.
+	HTTP *http.Client
+}
+
+// NewClient creates a Chef Server API client for the server at baseURL, authenticating as clientName
+// with the PEM encoded RSA private key in keyPEM.
+func NewClient(baseURL, clientName string, keyPEM []byte) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Chef Server URL %q: %w", baseURL, err)
+	}
+
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid Chef Server URL %q: scheme and host are required", baseURL)
+	}
+
+	key, err := ParseKey(keyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		BaseURL:    u,
+		ClientName: clientName,
+		Key:        key,
+		HTTP:       &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// ParseKey decodes a PEM encoded RSA private key, either in PKCS #1 or PKCS #8 form.
+func ParseKey(keyPEM []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("no PEM encoded private key found")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing private key: %w", err)
+	}
+
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not an RSA key")
+	}
+
+	return rsaKey, nil
+}
+
+// Get requests endpoint, relative to the organization's URL, and decodes its JSON response into v.
+// Endpoints not found return an error wrapping fs.ErrNotExist.
+func (c *Client) Get(endpoint string, v any) error {
+	u := *c.BaseURL
+	u.Path = path.Join(u.Path, endpoint)
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("failed creating request: %w", err)
+	}
+
+	if err := c.sign(req, nil); err != nil {
+		return fmt.Errorf("failed signing request: %w", err)
+	}
+
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed requesting %s: %w", endpoint, err)
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%s: %w", endpoint, fs.ErrNotExist)
+	case res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("%s: %s: %w", endpoint, res.Status, fs.ErrPermission)
+	case res.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("%s: unexpected response %s: %s", endpoint, res.Status, bytes.TrimSpace(body))
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed decoding %s response: %w", endpoint, err)
+	}
+
+	return nil
+}
+
+// sign adds the authentication headers expected by the Chef Server to req.
+func (c *Client) sign(req *http.Request, body []byte) error {
+	timestamp := time.Now().UTC().Format(time.RFC3339)
+	contentHash := hash(body)
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Chef-Version", "17.0.0")
+	req.Header.Set("X-Ops-Server-API-Version", "1")
+	req.Header.Set("X-Ops-Sign", "algorithm=sha256;version=1.3")
+	req.Header.Set("X-Ops-Timestamp", timestamp)
+	req.Header.Set("X-Ops-UserId", c.ClientName)
+	req.Header.Set("X-Ops-Content-Hash", contentHash)
+
+	digest := sha256.Sum256([]byte(CanonicalRequest(req.Method, req.URL.Path, contentHash, timestamp, c.ClientName)))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, c.Key, crypto.SHA256, digest[:])
+	if err != nil {
+		return err
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(sig)
+	for i := 0; len(encoded) > 0; i++ {
+		n := 60
+		if len(encoded) < n {
+			n = len(encoded)
+		}
+		req.Header.Set(fmt.Sprintf("X-Ops-Authorization-%d", i+1), encoded[:n])
+		encoded = encoded[n:]
+	}
+
+	return nil
+}
+
+// CanonicalRequest returns the string signed by clients, as defined by version 1.3
+// of the Chef Server's signing protocol.
+func CanonicalRequest(method, urlPath, contentHash, timestamp, userID string) string {
+	return strings.Join([]string{
+		"Method:" + strings.ToUpper(method),
+		"Path:" + canonicalPath(urlPath),
+		"X-Ops-Content-Hash:" + contentHash,
+		"X-Ops-Sign:version=1.3",
+		"X-Ops-Timestamp:" + timestamp,
+		"X-Ops-UserId:" + userID,
+		"X-Ops-Server-API-Version:1",
+	}, "\n")
+}
+
+// canonicalPath squeezes repeated slashes and removes the trailing one, if any.
+func canonicalPath(p string) string {
+	for strings.Contains(p, "//") {
+		p = strings.ReplaceAll(p, "//", "/")
+	}
+
+	if len(p) > 1 {
+		p = strings.TrimSuffix(p, "/")
+	}
+
+	return p
+}
+
+// hash returns the base64 encoded SHA-256 digest of body.
+func hash(body []byte) string {
+	sum := sha256.Sum256(body)
+
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,168 @@
+package chefserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"slack/whisk/internal/memfs"
+)
+
+// FS is a read-only filesystem fetching roles, environments and cookbooks metadata from a
+// Chef Server on demand, laid out like a chef-repo:
+//
+//	roles/<role_name>.json
+//	environments/<environment_name>.json
+//	cookbooks/<cookbook_name>/metadata.json
+//
+// Cookbooks are served at their latest uploaded version. Responses are cached for the lifetime of FS.
+type FS struct {
+	client *Client
+
+	// mu guards cache.
+	mu sync.Mutex
+	// cache holds the contents of files and directory listings fetched so far, keyed by path.
+	cache map[string][]byte
+}
+
+// collections are the top-level directories served, mapped to the suffix of their files.
+var collections = map[string]string{
+	"cookbooks":    "",
+	"environments": ".json",
+	"roles":        ".json",
+}
+
+// NewFS returns a filesystem backed by the Chef Server client c.
+func NewFS(c *Client) *FS {
+	return &FS{client: c, cache: make(map[string][]byte)}
+}
+
+// Open opens the named file or directory.
+func (f *FS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if name == "." {
+		var entries []fs.DirEntry
+		for c := range collections {
+			entries = append(entries, memfs.NewDirInfo(c))
+		}
+
+		return memfs.NewDir(name, entries), nil
+	}
+
+	parts := strings.Split(name, "/")
+	suffix, ok := collections[parts[0]]
+	switch {
+	case !ok:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	case len(parts) == 1:
+		names, err := f.list(parts[0])
+		if err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+
+		entries := make([]fs.DirEntry, 0, len(names))
+		for _, n := range names {
+			if suffix == "" {
+				entries = append(entries, memfs.NewDirInfo(n))
+				continue
+			}
+			entries = append(entries, memfs.NewInfo(n+suffix, 0o444, 0))
+		}
+
+		return memfs.NewDir(name, entries), nil
+	case parts[0] == "cookbooks" && len(parts) == 2:
+		if _, err := f.metadata(parts[1]); err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+
+		return memfs.NewDir(name, []fs.DirEntry{memfs.NewInfo("metadata.json", 0o444, 0)}), nil
+	case parts[0] == "cookbooks" && len(parts) == 3 && parts[2] == "metadata.json":
+		data, err := f.metadata(parts[1])
+		if err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+
+		return memfs.NewFile(name, 0o444, data), nil
+	case parts[0] != "cookbooks" && len(parts) == 2 && strings.HasSuffix(parts[1], suffix):
+		data, err := f.fetch(name, path.Join(parts[0], strings.TrimSuffix(parts[1], suffix)))
+		if err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+
+		return memfs.NewFile(name, 0o444, data), nil
+	default:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+}
+
+// list returns the sorted names of the objects in a collection, like roles or cookbooks.
+func (f *FS) list(collection string) ([]string, error) {
+	data, err := f.fetch(collection, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	var objects map[string]json.RawMessage
+	if err := json.Unmarshal(data, &objects); err != nil {
+		return nil, fmt.Errorf("failed decoding %s: %w", collection, err)
+	}
+
+	names := make([]string, 0, len(objects))
+	for n := range objects {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// metadata returns the metadata of the latest version of a cookbook.
+func (f *FS) metadata(cookbook string) ([]byte, error) {
+	data, err := f.fetch(path.Join("cookbooks", cookbook, "metadata.json"), path.Join("cookbooks", cookbook, "_latest"))
+	if err != nil {
+		return nil, err
+	}
+
+	var version struct {
+		Metadata json.RawMessage `json:"metadata"`
+	}
+
+	if err := json.Unmarshal(data, &version); err != nil {
+		return nil, fmt.Errorf("failed decoding %s metadata: %w", cookbook, err)
+	}
+
+	if len(version.Metadata) == 0 {
+		return nil, fmt.Errorf("metadata not found in %s latest version", cookbook)
+	}
+
+	return version.Metadata, nil
+}
+
+// fetch returns the raw response of an API endpoint, cached under key.
+func (f *FS) fetch(key, endpoint string) ([]byte, error) {
+	f.mu.Lock()
+	data, ok := f.cache[key]
+	f.mu.Unlock()
+
+	if ok {
+		return data, nil
+	}
+
+	var raw json.RawMessage
+	if err := f.client.Get(endpoint, &raw); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	f.cache[key] = raw
+	f.mu.Unlock()
+
+	return raw, nil
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"slack/whisk"
+	"slack/whisk/chef"
+
+	"github.com/spf13/cobra"
+	"github.com/xlab/treeprint"
+)
+
+var berksCmd = &cobra.Command{
+	Use:   "berks [flags] <berksfile_lock_path>",
+	Short: "Analyzes the dependency graph resolved in a Berksfile.lock",
+	Long: `Loads the GRAPH section of a Berksfile.lock and finds its strongly connected components and
+cycles. With --cross-check, dependencies of every locked cookbook available locally are also
+compared against the ones declared in its metadata, reporting any discrepancy. Cookbooks sourced
+from a path: are read from it, while the rest are looked up in the cookbook paths. Cookbooks
+sourced from git:, or from the Supermarket and not found in the cookbook paths, are skipped.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("a Berksfile.lock path is required")
+		}
+
+		return nil
+	},
+	RunE: berks,
+}
+
+// Command line flags for the berks subcommand.
+var (
+	berksCrossCheck bool
+	berksFormat     string
+)
+
+// init Initializes command line flags supported.
+func init() {
+	flagSet := berksCmd.Flags()
+	flagSet.BoolVar(&berksCrossCheck, "cross-check", false, "compare locked dependencies against cookbooks metadata")
+	flagSet.StringVarP(&berksFormat, "output", "o", "ascii", "Output format, either ascii or json")
+}
+
+// berks is a Cobra function handler for the berks subcommand.
+func berks(cmd *cobra.Command, args []string) error {
+	fsys, paths, done, err := openSource(append(strings.Split(cookbookPath, ","), args[0]))
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	lock, err := chef.NewBerksfileLockFS(fsys, paths[len(paths)-1])
+	if err != nil {
+		return fmt.Errorf("failed loading Berksfile.lock: %w", err)
+	}
+
+	tree := treeprint.New()
+	handler := whisk.NewHandler(paths[:len(paths)-1], "", whisk.WithFS(fsys))
+	handler.WalkBerksfileLock(lock, tree)
+
+	if err := handler.FindSCCs(); err != nil {
+		return fmt.Errorf("failed to find strongly connected components: %w", err)
+	}
+
+	if err := handler.FindCycles(); err != nil {
+		return fmt.Errorf("failed to enumerate distinct cyles: %w", err)
+	}
+
+	var discrepancies []whisk.Discrepancy
+	if berksCrossCheck {
+		if discrepancies, err = handler.CheckBerksfileLock(lock); err != nil {
+			return fmt.Errorf("failed to cross-check Berksfile.lock: %w", err)
+		}
+	}
+
+	if berksFormat == "json" {
+		doc := handler.Document()
+		doc.Discrepancies = discrepancies
+
+		if err := doc.Encode(os.Stdout); err != nil {
+			return fmt.Errorf("failed to encode graph to JSON: %w", err)
+		}
+	} else {
+		handler.ASCII(tree, os.Stdout)
+
+		if berksCrossCheck {
+			fmt.Fprintf(os.Stdout, "\n\n🔍 Discrepancies with cookbooks metadata: %d\n\n", len(discrepancies))
+			if len(discrepancies) == 0 {
+				fmt.Fprintf(os.Stdout, "None! 🍻 🎉 \n\n")
+			}
+
+			for i, d := range discrepancies {
+				i++
+				if d.Dependency == "" {
+					fmt.Fprintf(os.Stdout, "%d. %s: %s\n", i, d.Cookbook, d.Reason)
+					continue
+				}
+				fmt.Fprintf(os.Stdout, "%d. %s -> %s: %s\n", i, d.Cookbook, d.Dependency, d.Reason)
+			}
+		}
+	}
+
+	if len(discrepancies) > 0 {
+		return fmt.Errorf("%d discrepancies found between Berksfile.lock and cookbooks metadata", len(discrepancies))
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// codeQualityIssue is an issue of a GitLab Code Quality report.
+type codeQualityIssue struct {
+	Description string              `json:"description"`
+	CheckName   string              `json:"check_name"`
+	Fingerprint string              `json:"fingerprint"`
+	Severity    string              `json:"severity"`
+	Location    codeQualityLocation `json:"location"`
+}
+
+// codeQualityLocation is the file and line an issue is found at.
+type codeQualityLocation struct {
+	Path  string           `json:"path"`
+	Lines codeQualityLines `json:"lines"`
+}
+
+// codeQualityLines are the lines an issue spans.
+type codeQualityLines struct {
+	Begin int `json:"begin"`
+}
+
+// writeCodeQuality writes the findings as a GitLab Code Quality report, which merge requests
+// show on the file and line at fault.
+func writeCodeQuality(w io.Writer, findings []finding) error {
+	issues := make([]codeQualityIssue, 0, len(findings))
+	for _, f := range findings {
+		line := f.location.line
+		// GitLab requires a line, even for issues about the whole file.
+		if line == 0 {
+			line = 1
+		}
+
+		severity := "major"
+		if f.level == levelWarning {
+			severity = "minor"
+		}
+
+		path := repoPath(f.location.file)
+		// fingerprints identify issues across pipelines, so they must not depend on the message, which
+		// lists the roles cycles and components are found in.
+		key := f.key
+		if key == "" {
+			key = f.message
+		}
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%s\x00%d", f.rule, key, path, line)))
+
+		issues = append(issues, codeQualityIssue{
+			Description: f.message,
+			CheckName:   f.rule,
+			Fingerprint: hex.EncodeToString(sum[:]),
+			Severity:    severity,
+			Location:    codeQualityLocation{Path: path, Lines: codeQualityLines{Begin: line}},
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+
+	return enc.Encode(issues)
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+// fingerprint returns the fingerprint of the finding in a Code Quality report.
+func fingerprint(c *qt.C, f finding) string {
+	var b bytes.Buffer
+	c.Assert(writeCodeQuality(&b, []finding{f}), qt.IsNil)
+
+	var issues []codeQualityIssue
+	c.Assert(json.Unmarshal(b.Bytes(), &issues), qt.IsNil)
+	c.Assert(issues, qt.HasLen, 1)
+
+	return issues[0].Fingerprint
+}
+
+func TestCodeQualityFingerprint(t *testing.T) {
+	t.Parallel()
+
+	cycle := finding{
+		rule:     ruleCircularDependency,
+		message:  "Circular dependency b -> c -> b, found in roles: web",
+		key:      "b,c,b",
+		location: location{file: "cookbooks/b/metadata.rb", line: 2},
+	}
+
+	tests := []struct {
+		name     string
+		update   func(f *finding)
+		expected bool
+	}{
+		{"it should not change when the cycle is found in other roles", func(f *finding) { f.message = "Circular dependency b -> c -> b, found in roles: db, web" }, true},
+		{"it should change with the cycle", func(f *finding) { f.key = "b,d,b" }, false},
+		{"it should change with the rule", func(f *finding) { f.rule = ruleMaxCycles }, false},
+		{"it should change with the line", func(f *finding) { f.location.line = 3 }, false},
+		{"it should fall back to the message without a key", func(f *finding) { f.key = "" }, false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := qt.New(t)
+
+			updated := cycle
+			tt.update(&updated)
+			c.Assert(fingerprint(c, updated) == fingerprint(c, cycle), qt.Equals, tt.expected)
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"slack/whisk"
+	"slack/whisk/artifact"
+	"slack/whisk/chef"
+
+	"github.com/spf13/cobra"
+	"github.com/xlab/treeprint"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff [flags] --before <dir|git_ref> --after <dir|git_ref> <role_path>",
+	Short: "Compares the dependency graph of a role between two revisions of the chef-repo",
+	Long: `Builds the dependency graph of a role for two revisions of the chef-repo and reports added and
+removed cookbooks and dependencies, strongly connected components that were introduced, resolved,
+merged, split, grew or shrank, as well as cycles introduced or resolved.
+
+Revisions are either chef-repo directories or git references of the repository in the current
+working directory, read without checking them out. The role path and relative cookbook paths
+are resolved within each revision.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("a role file path is required")
+		}
+
+		if diffBefore == "" || diffAfter == "" {
+			return errors.New("both --before and --after revisions are required")
+		}
+
+		return nil
+	},
+	RunE: diff,
+}
+
+// Command line flags for the diff subcommand.
+var (
+	diffBefore string
+	diffAfter  string
+	diffFormat string
+)
+
+// init Initializes command line flags supported.
+func init() {
+	flagSet := diffCmd.Flags()
+	flagSet.StringVar(&diffBefore, "before", "", "chef-repo directory or git reference to compare from")
+	flagSet.StringVar(&diffAfter, "after", "", "chef-repo directory or git reference to compare to")
+	flagSet.StringVarP(&diffFormat, "output", "o", "ascii", "Output format, either ascii or json")
+}
+
+// diff is a Cobra function handler for the diff subcommand.
+func diff(cmd *cobra.Command, args []string) error {
+	before, err := analyzeRevision(diffBefore, args[0])
+	if err != nil {
+		return fmt.Errorf("failed analyzing %q: %w", diffBefore, err)
+	}
+
+	after, err := analyzeRevision(diffAfter, args[0])
+	if err != nil {
+		return fmt.Errorf("failed analyzing %q: %w", diffAfter, err)
+	}
+
+	d := whisk.Diff(before.Result(), after.Result())
+
+	if diffFormat == "json" {
+		if err := d.JSON(os.Stdout); err != nil {
+			return fmt.Errorf("failed to encode diff to JSON: %w", err)
+		}
+
+		return nil
+	}
+
+	d.ASCII(os.Stdout)
+
+	return nil
+}
+
+// analyzeRevision analyzes the role stored in rolePath for a revision of the chef-repo, which
+// is either a directory or a git revision read straight from the repository.
+func analyzeRevision(revision, rolePath string) (*whisk.Handler, error) {
+	paths := append(strings.Split(cookbookPath, ","), rolesRoot(rolePath), rolePath)
+
+	if info, err := os.Stat(revision); err == nil && info.IsDir() {
+		for i, p := range paths {
+			if !filepath.IsAbs(p) {
+				paths[i] = filepath.Join(revision, p)
+			}
+		}
+
+		fsys, err := artifact.Mount(chef.LocalFS, paths)
+		if err != nil {
+			return nil, fmt.Errorf("failed opening cookbook artifacts: %w", err)
+		}
+
+		return analyzeRole(fsys, paths[:len(paths)-2], paths[len(paths)-2], paths[len(paths)-1], treeprint.New())
+	}
+
+	fsys, paths, done, err := openRevision(revision, paths)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	return analyzeRole(fsys, paths[:len(paths)-2], paths[len(paths)-2], paths[len(paths)-1], treeprint.New())
+}
@@ -0,0 +1,295 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"slack/whisk"
+)
+
+// Rules for what is found analyzing a role within thresholds.
+const (
+	// ruleCircularDependency reports cycles found analyzing a role, regardless of thresholds.
+	ruleCircularDependency = "circular-dependency"
+	// ruleStronglyConnectedComponent reports strongly connected components not exceeding thresholds.
+	ruleStronglyConnectedComponent = "strongly-connected-component"
+)
+
+// ruleDescriptions describes the rules findings are reported for.
+var ruleDescriptions = map[string]string{
+	ruleMaxCycles:                  "Roles must not exceed the maximum number of circular dependencies",
+	ruleMaxSCCs:                    "Roles must not exceed the maximum number of strongly connected components",
+	ruleMaxCookbooksPerSCC:         "Strongly connected components must not exceed the maximum number of cookbooks",
+	ruleAnalysisError:              "Roles' dependency graph must load",
+	ruleCircularDependency:         "Cookbooks must not depend on each other circularly",
+	ruleStronglyConnectedComponent: "Cookbooks should not form strongly connected components",
+}
+
+// init adds the role validation rules to the rules described.
+func init() {
+	for id, desc := range whisk.Rules {
+		ruleDescriptions[id] = desc
+	}
+}
+
+// Levels findings are reported with.
+const (
+	// levelError is the level of findings failing linting.
+	levelError = "error"
+	// levelWarning is the level of findings reported without failing linting.
+	levelWarning = "warning"
+)
+
+// location is a place in a file. Line is 0 when pointing to the whole file.
+type location struct {
+	file    string
+	line    int
+	message string
+}
+
+// finding is a problem found, located at the file and line at fault, such as a role
+// validation violation or the depends line creating a cycle.
+type finding struct {
+	// rule is the rule the finding is reported for.
+	rule string
+	// level is either levelError or levelWarning.
+	level string
+	// message describes the finding.
+	message string
+	// key identifies what the finding is about, like a cycle's cookbooks, regardless of the roles it's
+	// found in, for fingerprints to stay stable as roles change. The message is used when empty.
+	key string
+	// location is where the finding was found.
+	location location
+	// related are other locations involved, like the rest of the dependencies of a cycle.
+	related []location
+}
+
+// lintFindings returns the findings of the linting reports. Role validation violations are located in
+// role files, while every cycle and strongly connected component is located at the depends line of the
+// metadata file that creates it, with the rest of the involved dependencies as related locations. Cycles
+// and components are warnings, unless they exceed thresholds in any role, and are reported once even
+// when found in multiple roles.
+func lintFindings(reports []*roleReport) []finding {
+	var findings []finding
+
+	var keys []string
+	shared := make(map[string]*finding)
+	roles := make(map[string][]string)
+	add := func(key, role, level string, fn func() finding) {
+		prev, ok := shared[key]
+		if !ok {
+			keys = append(keys, key)
+		}
+
+		// a finding exceeding thresholds in any role replaces its warning.
+		if !ok || (prev.level == levelWarning && level == levelError) {
+			f := fn()
+			f.level = level
+			shared[key] = &f
+		}
+		roles[key] = append(roles[key], role)
+	}
+
+	for _, r := range reports {
+		for _, v := range r.violations {
+			findings = append(findings, violationFinding(v))
+		}
+
+		for _, v := range r.warnings {
+			findings = append(findings, violationFinding(v))
+		}
+
+		if r.err != nil {
+			findings = append(findings, finding{
+				rule:     ruleAnalysisError,
+				level:    levelError,
+				message:  r.err.Error(),
+				location: location{file: r.role.Path},
+			})
+		}
+
+		breached := make(map[string]bool)
+		for _, b := range r.breaches {
+			if b.rule == ruleMaxCookbooksPerSCC {
+				breached[b.rule+sccKey(r.result.Sccs[b.scc])] = true
+				continue
+			}
+			breached[b.rule] = true
+		}
+
+		for _, c := range r.result.Cycles {
+			c := c
+			rule, level := ruleCircularDependency, levelWarning
+			if breached[ruleMaxCycles] {
+				rule, level = ruleMaxCycles, levelError
+			}
+
+			add("cycle:"+strings.Join(c, ","), r.role.Name, level, func() finding {
+				return cycleFinding(r.handler, r.role.Path, rule, c)
+			})
+		}
+
+		for _, scc := range r.result.Sccs {
+			scc := scc
+			rule, level := ruleStronglyConnectedComponent, levelWarning
+			switch {
+			case breached[ruleMaxCookbooksPerSCC+sccKey(scc)]:
+				rule, level = ruleMaxCookbooksPerSCC, levelError
+			case breached[ruleMaxSCCs]:
+				rule, level = ruleMaxSCCs, levelError
+			}
+
+			add("scc:"+sccKey(scc), r.role.Name, level, func() finding {
+				return sccFinding(r.handler, r.result.G, r.role.Path, rule, scc)
+			})
+		}
+	}
+
+	for _, key := range keys {
+		f := shared[key]
+		sort.Strings(roles[key])
+		f.message = fmt.Sprintf("%s, found in roles: %s", f.message, strings.Join(roles[key], ", "))
+		findings = append(findings, *f)
+	}
+
+	return findings
+}
+
+// handlerFindings returns the findings of a single analysis: the role validation violations
+// found, and every cycle located at the depends line creating it.
+func handlerFindings(h *whisk.Handler) []finding {
+	r := h.Result()
+
+	var findings []finding
+	for _, v := range r.Violations {
+		findings = append(findings, violationFinding(v))
+	}
+
+	for _, c := range r.Cycles {
+		findings = append(findings, cycleFinding(h, "", ruleCircularDependency, c))
+	}
+
+	return findings
+}
+
+// violationFinding returns the finding of a role validation violation, located at the role's file.
+// Only violations of failingRules are errors.
+func violationFinding(v whisk.Violation) finding {
+	level := levelWarning
+	if failingRules[v.Rule] {
+		level = levelError
+	}
+
+	return finding{
+		rule:     v.Rule,
+		level:    level,
+		message:  fmt.Sprintf("%s: %s", v.Role, v.Message),
+		location: location{file: v.Path},
+	}
+}
+
+// cycleFinding returns the finding of a cycle, located at the dependency closing it from its first cookbook.
+func cycleFinding(h *whisk.Handler, fallback, rule string, cycle []string) finding {
+	var edges [][2]string
+	for i := 0; i < len(cycle)-1; i++ {
+		edges = append(edges, [2]string{cycle[i], cycle[i+1]})
+	}
+
+	return finding{
+		rule:     rule,
+		level:    levelError,
+		message:  fmt.Sprintf("Circular dependency %s", strings.Join(cycle, " -> ")),
+		key:      strings.Join(cycle, ","),
+		location: dependencyLocation(h, fallback, edges[0]),
+		related:  dependencyLocations(h, fallback, edges[1:]),
+	}
+}
+
+// sccFinding returns the finding of a strongly connected component, located at the first dependency
+// between its cookbooks.
+func sccFinding(h *whisk.Handler, g map[string][]string, fallback, rule string, scc []string) finding {
+	members := make(map[string]bool, len(scc))
+	for _, c := range scc {
+		members[c] = true
+	}
+
+	sorted := append([]string(nil), scc...)
+	sort.Strings(sorted)
+
+	var edges [][2]string
+	for _, c := range sorted {
+		for _, dep := range g[c] {
+			if members[dep] {
+				edges = append(edges, [2]string{c, dep})
+			}
+		}
+	}
+
+	f := finding{
+		rule:     rule,
+		level:    levelError,
+		message:  fmt.Sprintf("Strongly connected component of %d cookbooks: %s", len(scc), strings.Join(sorted, ", ")),
+		key:      sccKey(scc),
+		location: location{file: fallback},
+	}
+
+	if len(edges) > 0 {
+		f.location = dependencyLocation(h, fallback, edges[0])
+		f.related = dependencyLocations(h, fallback, edges[1:])
+	}
+
+	return f
+}
+
+// dependencyLocation returns the location of the depends line creating the given edge. Dependencies
+// of cookbooks without metadata, like missing cookbooks, are located at the fallback file instead.
+func dependencyLocation(h *whisk.Handler, fallback string, edge [2]string) location {
+	message := fmt.Sprintf("%s depends on %s", edge[0], edge[1])
+
+	file, line := h.DependencyLocation(edge[0], edge[1])
+	if file == "" {
+		return location{file: fallback, message: message}
+	}
+
+	return location{file: file, line: line, message: message}
+}
+
+// dependencyLocations returns the locations of the given edges.
+func dependencyLocations(h *whisk.Handler, fallback string, edges [][2]string) []location {
+	var locations []location
+	for _, e := range edges {
+		locations = append(locations, dependencyLocation(h, fallback, e))
+	}
+
+	return locations
+}
+
+// sccKey identifies a strongly connected component regardless of the order of its cookbooks.
+func sccKey(scc []string) string {
+	sorted := append([]string(nil), scc...)
+	sort.Strings(sorted)
+
+	return strings.Join(sorted, ",")
+}
+
+// repoPath returns p relative to the current working directory, as code scanning tools and CI
+// systems expect paths relative to the repository root.
+func repoPath(p string) string {
+	if p == "" {
+		return ""
+	}
+
+	if filepath.IsAbs(p) {
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, p); err == nil && !strings.HasPrefix(rel, "..") {
+				p = rel
+			}
+		}
+	}
+
+	return filepath.ToSlash(filepath.Clean(p))
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// githubData escapes annotation messages, as GitHub Actions workflow commands require.
+var githubData = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+
+// githubProperty escapes annotation properties, as GitHub Actions workflow commands require.
+var githubProperty = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+
+// writeGitHubAnnotations writes the findings as GitHub Actions error or warning annotations, which
+// are shown on the file and line at fault in pull requests.
+func writeGitHubAnnotations(w io.Writer, findings []finding) error {
+	for _, f := range findings {
+		var props []string
+		if file := repoPath(f.location.file); file != "" {
+			props = append(props, "file="+githubProperty.Replace(file))
+			if f.location.line > 0 {
+				props = append(props, fmt.Sprintf("line=%d", f.location.line))
+			}
+		}
+		props = append(props, "title="+githubProperty.Replace(f.rule))
+
+		if _, err := fmt.Fprintf(w, "::%s %s::%s\n", f.level, strings.Join(props, ","), githubData.Replace(f.message)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
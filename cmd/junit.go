@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"slack/whisk/junit"
+)
+
+// writeJUnit writes the linting reports as a JUnit report, where every role is a test case. Roles
+// not passing linting rules fail, carrying the linting errors found, while roles whose dependency
+// graph couldn't be loaded error, on top of failing if they also break rules.
+func writeJUnit(w io.Writer, reports []*roleReport, elapsed time.Duration) error {
+	cases := make([]junit.TestCase, 0, len(reports))
+	for _, r := range reports {
+		tc := junit.TestCase{
+			Name:      r.role.Name,
+			ClassName: r.role.Path,
+			Time:      junit.Seconds(r.elapsed),
+		}
+
+		if r.err != nil {
+			tc.Error = &junit.Problem{Message: r.err.Error(), Type: ruleAnalysisError}
+		}
+
+		var messages, rules []string
+		for _, v := range r.violations {
+			messages = append(messages, v.String())
+			rules = append(rules, v.Rule)
+		}
+
+		for _, b := range r.breaches {
+			messages = append(messages, b.message)
+			rules = append(rules, b.rule)
+		}
+
+		if len(messages) > 0 {
+			tc.Failure = &junit.Problem{
+				Message: fmt.Sprintf("%d linting errors found", len(messages)),
+				Type:    strings.Join(dedup(rules), ","),
+				Text:    strings.Join(messages, "\n"),
+			}
+		}
+
+		cases = append(cases, tc)
+	}
+
+	return junit.Encode(w, "whisk", elapsed, junit.NewSuite("lint", elapsed, cases))
+}
+
+// dedup removes repeated strings, keeping the first occurrence of each.
+func dedup(a []string) []string {
+	seen := make(map[string]bool, len(a))
+	var out []string
+	for _, s := range a {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+
+	return out
+}
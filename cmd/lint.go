@@ -5,9 +5,9 @@ import (
 	"fmt"
 	"io/fs"
 	"os"
-	"path/filepath"
 	"strings"
 	"text/template"
+	"time"
 
 	"slack/whisk"
 	"slack/whisk/chef"
@@ -40,19 +40,40 @@ var lintCmd = &cobra.Command{
 	RunE: lint,
 }
 
+// Lint rules for thresholds.
+const (
+	ruleMaxCycles          = "max-cycles"
+	ruleMaxSCCs            = "max-sccs"
+	ruleMaxCookbooksPerSCC = "max-cookbooks-per-scc"
+	// ruleAnalysisError reports roles whose dependency graph failed to load.
+	ruleAnalysisError = "analysis-error"
+)
+
+// failingRules are the role validation rules failing linting, as Chef runs break on them. Violations
+// of any other rule are reported as warnings.
+var failingRules = map[string]bool{
+	whisk.RuleMissingRole:     true,
+	whisk.RuleMissingCookbook: true,
+	whisk.RuleMissingRecipe:   true,
+}
+
 // Command line flags for linting rules supported.
 var (
 	maxCycles          uint
 	maxSCCs            uint
 	maxCookbooksPerSCC uint
+	skipRules          []string
+	lintFormat         string
 )
 
 // init Initializes command line flags supported.
 func init() {
 	flagSet := lintCmd.Flags()
-	flagSet.UintVar(&maxCycles, "max-cycles", 0, "maximum number of distinct circular dependencies accepted")
-	flagSet.UintVar(&maxSCCs, "max-sccs", 0, "maximum number of unique strongly connected components")
-	flagSet.UintVar(&maxCookbooksPerSCC, "max-cookbooks-per-scc", 0, "maximum number of cookbooks per strongly connected component")
+	flagSet.UintVar(&maxCycles, ruleMaxCycles, 0, "maximum number of distinct circular dependencies accepted")
+	flagSet.UintVar(&maxSCCs, ruleMaxSCCs, 0, "maximum number of unique strongly connected components")
+	flagSet.UintVar(&maxCookbooksPerSCC, ruleMaxCookbooksPerSCC, 0, "maximum number of cookbooks per strongly connected component")
+	flagSet.StringSliceVar(&skipRules, "skip-rules", nil, "role validation rules to skip, like role-name-mismatch or missing-recipe")
+	flagSet.StringVar(&lintFormat, "format", "text", "Output format, either text, sarif, junit, github, gitlab-codequality or ndjson")
 }
 
 // closestMatch is used to give people context on successful linting results, in case they are using
@@ -70,38 +91,110 @@ type closestMatch struct {
 
 // linter defines a simple linter for Chef roles and cookbooks.
 type linter struct {
-	cookbookPath   string
+	// fsys is the filesystem roles and cookbooks are loaded from.
+	fsys           fs.FS
+	cookbookPaths  []string
 	rolesDir       string
 	eg             *errgroup.Group
-	roles          int
 	closestMatches map[string]*closestMatch
+	// handlerOpts configure the handlers analyzing every role.
+	handlerOpts []whisk.Option
+	// onReport, when set, is called with every role's report as soon as the role is linted.
+	onReport func(*roleReport) error
 
 	// rules
 	maxCycles          uint
 	maxSCCs            uint
 	maxCookbooksPerSCC uint
+	skipRules          map[string]bool
+}
+
+// breach is a linting threshold exceeded by a role.
+type breach struct {
+	// rule is the threshold's rule.
+	rule string
+	// message describes the breach.
+	message string
+	// scc is the index of the strongly connected component exceeding max-cookbooks-per-scc.
+	scc int
+}
+
+// roleReport holds the linting results of a single role.
+type roleReport struct {
+	// role is the role linted.
+	role *chef.Role
+	// handler holds the role's analysis, used to locate dependencies in cookbooks' metadata.
+	handler *whisk.Handler
+	// result is the role's analysis result.
+	result whisk.Result
+	// violations are the failing role validation rules not met, except those skipped.
+	violations []whisk.Violation
+	// warnings are the other role validation rules not met, except those skipped.
+	warnings []whisk.Violation
+	// breaches are the linting thresholds exceeded.
+	breaches []breach
+	// err is the error found loading the role's dependency graph, if any.
+	err error
+	// elapsed is how long linting the role took.
+	elapsed time.Duration
+}
+
+// failed returns whether the role didn't pass linting.
+func (r *roleReport) failed() bool {
+	return r.err != nil || len(r.violations) > 0 || len(r.breaches) > 0
+}
+
+// errors returns the linting errors found in the role, in the order they are reported.
+func (r *roleReport) errors() []error {
+	var errs []error
+	for _, v := range r.violations {
+		errs = append(errs, errors.New(v.String()))
+	}
+
+	if r.err != nil {
+		errs = append(errs, r.err)
+	}
+
+	for _, b := range r.breaches {
+		errs = append(errs, errors.New(b.message))
+	}
+
+	return errs
 }
 
 // lint is a Cobra function handler for the lint subcommand.
 func lint(cmd *cobra.Command, args []string) error {
-	rolesDir := args[0]
+	switch lintFormat {
+	case "text", "sarif", "junit", "github", "gitlab-codequality", "ndjson":
+	default:
+		return fmt.Errorf("unsupported format %q", lintFormat)
+	}
+	start := time.Now()
+
+	// cookbookPath and revision are persistent flags defined in root.go
+	fsys, paths, done, err := openSource(append(strings.Split(cookbookPath, ","), args[0]))
+	if err != nil {
+		return err
+	}
+	defer done()
 
 	l := &linter{
-		cookbookPath: cookbookPath, // persistent flag defined in root.go
-		rolesDir:     rolesDir,
-		eg:           new(errgroup.Group),
-		roles:        0,
+		fsys:          fsys,
+		cookbookPaths: paths[:len(paths)-1],
+		rolesDir:      paths[len(paths)-1],
+		eg:            new(errgroup.Group),
+		handlerOpts:   handlerOptions(fsys),
 		closestMatches: map[string]*closestMatch{
-			"max-cycles": {
-				Metric: "max-cycles",
+			ruleMaxCycles: {
+				Metric: ruleMaxCycles,
 				Max:    int(maxCycles),
 			},
-			"max-sccs": {
-				Metric: "max-sccs",
+			ruleMaxSCCs: {
+				Metric: ruleMaxSCCs,
 				Max:    int(maxSCCs),
 			},
-			"max-cookbooks-per-scc": {
-				Metric: "max-cookbooks-per-scc",
+			ruleMaxCookbooksPerSCC: {
+				Metric: ruleMaxCookbooksPerSCC,
 				Max:    int(maxCookbooksPerSCC),
 			},
 		},
@@ -110,9 +203,61 @@ func lint(cmd *cobra.Command, args []string) error {
 		maxCycles:          maxCycles,
 		maxSCCs:            maxSCCs,
 		maxCookbooksPerSCC: maxCookbooksPerSCC,
+		skipRules:          make(map[string]bool),
+	}
+
+	for _, rule := range skipRules {
+		l.skipRules[rule] = true
+	}
+
+	if lintFormat == "ndjson" {
+		l.onReport = newNDJSONWriter(os.Stdout).write
+	}
+
+	reports, err := l.lintRoles()
+	if err != nil {
+		return fmt.Errorf("linting errors were found. \n\n %w", err)
+	}
+
+	var lr *multierror.Error
+	for _, r := range reports {
+		lr = multierror.Append(lr, r.errors()...)
+	}
+
+	switch lintFormat {
+	case "sarif":
+		if err := writeSARIF(os.Stdout, lintFindings(reports)); err != nil {
+			return fmt.Errorf("failed writing SARIF log: %w", err)
+		}
+	case "github":
+		if err := writeGitHubAnnotations(os.Stdout, lintFindings(reports)); err != nil {
+			return fmt.Errorf("failed writing GitHub annotations: %w", err)
+		}
+	case "gitlab-codequality":
+		if err := writeCodeQuality(os.Stdout, lintFindings(reports)); err != nil {
+			return fmt.Errorf("failed writing Code Quality report: %w", err)
+		}
+	case "junit":
+		if err := writeJUnit(os.Stdout, reports, time.Since(start)); err != nil {
+			return fmt.Errorf("failed writing JUnit report: %w", err)
+		}
+	}
+
+	if lintFormat != "text" {
+		if lr.ErrorOrNil() != nil {
+			return errors.New("linting errors were found")
+		}
+
+		return nil
 	}
 
-	if err := l.lintRoles(); err != nil {
+	for _, r := range reports {
+		for _, w := range r.warnings {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+		}
+	}
+
+	if err := lr.ErrorOrNil(); err != nil {
 		return fmt.Errorf("linting errors were found. \n\n %w", err)
 	}
 
@@ -126,98 +271,177 @@ func lint(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-// lintRoles walks Chef's roles directory and analyzes the digraph of every role found, returning
-// on the first role not meeting linting thresholds.
-func (l *linter) lintRoles() error {
-	if err := filepath.WalkDir(l.rolesDir, l.walkDirFunc); err != nil {
-		return fmt.Errorf("failed walking %q dir: %w", l.rolesDir, err)
+// lintRoles walks Chef's roles directory, recursively, and analyzes the digraph of every role found,
+// returning a report per role, in path order. Roles sharing the same name in different files are
+// reported upfront, since Chef would only keep one of them.
+func (l *linter) lintRoles() ([]*roleReport, error) {
+	paths, err := chef.FindRoles(l.fsys, l.rolesDir, include, exclude)
+	if err != nil {
+		return nil, fmt.Errorf("failed walking %q dir: %w", l.rolesDir, err)
 	}
 
-	fmt.Fprintf(os.Stderr, "\nLinting %d Chef roles...\n\n", l.roles)
+	var lr *multierror.Error
+	var roles []*chef.Role
+	seen := make(map[string]*chef.Role, len(paths))
+	for _, path := range paths {
+		role, err := chef.NewRoleFS(l.fsys, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed loading role %q: %w", path, err)
+		}
 
-	return l.eg.Wait()
-}
+		if dup, ok := seen[role.Name]; ok {
+			lr = multierror.Append(lr, fmt.Errorf("%s: role defined in both %q and %q", role.Name, dup.Path, role.Path))
+			continue
+		}
+		seen[role.Name] = role
+		roles = append(roles, role)
+	}
 
-// walkDirFunc runs for each role JSON file found and launches a goroutine
-// to do dependency analysis.
-func (l *linter) walkDirFunc(path string, d fs.DirEntry, err error) error {
-	if !strings.HasSuffix(path, ".json") && l.rolesDir != path {
-		return fs.SkipDir
+	if err := lr.ErrorOrNil(); err != nil {
+		return nil, err
 	}
 
-	// If there was any error stat()ing path, return it.
-	if err != nil {
-		return err
+	fmt.Fprintf(os.Stderr, "\nLinting %d Chef roles...\n\n", len(roles))
+
+	reports := make([]*roleReport, len(roles))
+	for i, role := range roles {
+		i, role := i, role
+		l.eg.Go(func() error {
+			start := time.Now()
+			r, err := l.lint(role)
+			if err != nil {
+				return err
+			}
+			r.elapsed = time.Since(start)
+			reports[i] = r
+
+			if l.onReport != nil {
+				return l.onReport(r)
+			}
+
+			return nil
+		})
 	}
 
-	// Do not attempt to lint the roles base dir.
-	if path == l.rolesDir {
-		return nil
+	if err := l.eg.Wait(); err != nil {
+		return nil, err
 	}
 
-	l.roles++
-	l.eg.Go(func() error {
-		return l.lint(path)
-	})
+	// closest matches are updated once all roles are linted, since goroutines can't share them.
+	for _, r := range reports {
+		l.updateClosestMatches(r)
+	}
 
-	return nil
+	return reports, nil
 }
 
-// lint runs linting for a single role.
-func (l *linter) lint(rolePath string) error {
-	handler := whisk.NewHandler(strings.Split(l.cookbookPath, ","), l.rolesDir)
+// lint runs linting for a single role, returning an error only when the role couldn't be validated.
+func (l *linter) lint(role *chef.Role) (*roleReport, error) {
+	handler := whisk.NewHandler(l.cookbookPaths, l.rolesDir, l.handlerOpts...)
+	r := &roleReport{role: role, handler: handler}
 
-	role, err := chef.NewRole(rolePath)
+	violations, err := handler.ValidateRole(role.Name)
 	if err != nil {
-		return fmt.Errorf("failed loading role %q: %w", rolePath, err)
+		return nil, fmt.Errorf("%s: failed validating role: %w", role.Name, err)
+	}
+
+	for _, v := range violations {
+		switch {
+		case l.skipRules[v.Rule]:
+		case failingRules[v.Rule]:
+			r.violations = append(r.violations, v)
+		default:
+			r.warnings = append(r.warnings, v)
+		}
 	}
 
 	if err := handler.WalkRole(role.Name, treeprint.New()); err != nil {
-		return fmt.Errorf("%s: %w", role.Name, err)
+		r.err = fmt.Errorf("%s: %w", role.Name, err)
+		return r, nil
 	}
 
 	if err := handler.FindSCCs(); err != nil {
-		return fmt.Errorf("%s: failed to find strongly connected components: %w", role.Name, err)
+		r.err = fmt.Errorf("%s: failed to find strongly connected components: %w", role.Name, err)
+		return r, nil
 	}
 
 	if err := handler.FindCycles(); err != nil {
-		return fmt.Errorf("%s: failed to enumerate distinct cyles: %w", role.Name, err)
+		r.err = fmt.Errorf("%s: failed to enumerate distinct cyles: %w", role.Name, err)
+		return r, nil
 	}
 
-	r := handler.Result()
-	var lr *multierror.Error
+	r.result = handler.Result()
+
+	// Missing cookbooks in the role's run list were reported validating it, unlike those only required
+	// by other cookbooks, which are found walking it with --allow-missing. Cookbooks both in the run list
+	// and required by other cookbooks are only reported once.
+	reported := make(map[string]bool)
+	for _, v := range violations {
+		if v.Rule == whisk.RuleMissingCookbook {
+			reported[v.Cookbook] = true
+		}
+	}
+
+	for _, m := range r.result.Missing {
+		if len(m.RequiredBy) == 0 || reported[m.Cookbook] || l.skipRules[whisk.RuleMissingCookbook] {
+			continue
+		}
+
+		r.violations = append(r.violations, whisk.Violation{
+			Rule:     whisk.RuleMissingCookbook,
+			Role:     role.Name,
+			Path:     role.Path,
+			Cookbook: m.Cookbook,
+			Message:  fmt.Sprintf("cookbook %q wasn't found in any cookbook path, required by %s", m.Cookbook, strings.Join(m.RequiredBy, ", ")),
+		})
+	}
 
-	cyclesFound := len(r.Cycles)
+	cyclesFound := len(r.result.Cycles)
 	if cyclesFound > int(l.maxCycles) {
-		lr = multierror.Append(lr, fmt.Errorf("%s: %d cycles found. Max threshold: %d", role.Name, cyclesFound, l.maxCycles))
+		r.breaches = append(r.breaches, breach{
+			rule:    ruleMaxCycles,
+			message: fmt.Sprintf("%s: %d cycles found. Max threshold: %d", role.Name, cyclesFound, l.maxCycles),
+		})
 	}
 
-	sccsFound := len(r.Sccs)
+	sccsFound := len(r.result.Sccs)
 	if sccsFound > int(l.maxSCCs) {
-		lr = multierror.Append(lr, fmt.Errorf("%s: %d sccs found. Max threshold: %d", role.Name, sccsFound, l.maxSCCs))
+		r.breaches = append(r.breaches, breach{
+			rule:    ruleMaxSCCs,
+			message: fmt.Sprintf("%s: %d sccs found. Max threshold: %d", role.Name, sccsFound, l.maxSCCs),
+		})
 	}
 
-	for i, scc := range r.Sccs {
+	for i, scc := range r.result.Sccs {
 		cookbooksFound := len(scc)
 		if cookbooksFound > int(l.maxCookbooksPerSCC) {
-			lr = multierror.Append(lr, fmt.Errorf("%s: %d cookbooks found in scc %d. Max threshold: %d", role.Name, cookbooksFound, i, l.maxCookbooksPerSCC))
+			r.breaches = append(r.breaches, breach{
+				rule:    ruleMaxCookbooksPerSCC,
+				message: fmt.Sprintf("%s: %d cookbooks found in scc %d. Max threshold: %d", role.Name, cookbooksFound, i, l.maxCookbooksPerSCC),
+				scc:     i,
+			})
 		}
+	}
+
+	return r, nil
+}
 
-		if m := l.closestMatches["max-cookbooks-per-scc"]; m.Value < cookbooksFound {
-			m.Value = cookbooksFound
-			m.Role = role.Name
+// updateClosestMatches keeps track of the roles closest to reaching every threshold.
+func (l *linter) updateClosestMatches(r *roleReport) {
+	for _, scc := range r.result.Sccs {
+		if m := l.closestMatches[ruleMaxCookbooksPerSCC]; m.Value < len(scc) {
+			m.Value = len(scc)
+			m.Role = r.role.Name
 		}
 	}
 
-	if m := l.closestMatches["max-cycles"]; m.Value < cyclesFound {
-		m.Value = cyclesFound
-		m.Role = role.Name
+	if m := l.closestMatches[ruleMaxCycles]; m.Value < len(r.result.Cycles) {
+		m.Value = len(r.result.Cycles)
+		m.Role = r.role.Name
 	}
 
-	if m := l.closestMatches["max-sccs"]; m.Value < sccsFound {
-		m.Value = sccsFound
-		m.Role = role.Name
+	if m := l.closestMatches[ruleMaxSCCs]; m.Value < len(r.result.Sccs) {
+		m.Value = len(r.result.Sccs)
+		m.Role = r.role.Name
 	}
-
-	return lr.ErrorOrNil()
 }
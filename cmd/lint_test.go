@@ -0,0 +1,266 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"sort"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"golang.org/x/sync/errgroup"
+
+	"slack/whisk"
+	"slack/whisk/chef"
+)
+
+// newTestLinter returns a linter of the roles in fsys' roles directory, loading cookbooks from its
+// cookbooks directory with the given handler options, with every threshold set to 0.
+func newTestLinter(fsys fs.FS, opts ...whisk.Option) *linter {
+	return &linter{
+		fsys:          fsys,
+		handlerOpts:   append([]whisk.Option{whisk.WithFS(fsys)}, opts...),
+		cookbookPaths: []string{"cookbooks"},
+		rolesDir:      "roles",
+		eg:            new(errgroup.Group),
+		closestMatches: map[string]*closestMatch{
+			ruleMaxCycles:          {Metric: ruleMaxCycles},
+			ruleMaxSCCs:            {Metric: ruleMaxSCCs},
+			ruleMaxCookbooksPerSCC: {Metric: ruleMaxCookbooksPerSCC},
+		},
+		skipRules: make(map[string]bool),
+	}
+}
+
+// lintTestRole lints the role stored at path in fsys, analyzing it with the given handler options.
+func lintTestRole(c *qt.C, fsys fs.FS, path string, opts ...whisk.Option) *roleReport {
+	role, err := chef.NewRoleFS(fsys, path)
+	c.Assert(err, qt.IsNil)
+
+	r, err := newTestLinter(fsys, opts...).lint(role)
+	c.Assert(err, qt.IsNil)
+
+	return r
+}
+
+// findingRules returns the rules of the findings, in order.
+func findingRules(findings []finding) []string {
+	var rules []string
+	for _, f := range findings {
+		rules = append(rules, f.rule)
+	}
+
+	return rules
+}
+
+func TestLintAnalysisErrorsWithViolations(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+
+	// app's missing recipe violates a rule, while its missing dependency fails the analysis.
+	fsys := fstest.MapFS{
+		"roles/web.json":                   {Data: []byte(`{"name": "web", "json_class": "Chef::Role", "chef_type": "role", "run_list": ["recipe[app::missing]"]}`)},
+		"cookbooks/app/metadata.rb":        {Data: []byte("name 'app'\ndepends 'vault'\n")},
+		"cookbooks/app/recipes/default.rb": {Data: []byte("")},
+	}
+
+	r := lintTestRole(c, fsys, "roles/web.json")
+	c.Assert(r.violations, qt.HasLen, 1)
+	c.Assert(r.err, qt.IsNotNil)
+
+	var messages []string
+	for _, err := range r.errors() {
+		messages = append(messages, err.Error())
+	}
+	c.Assert(messages, qt.DeepEquals, []string{r.violations[0].String(), r.err.Error()})
+
+	c.Assert(findingRules(lintFindings([]*roleReport{r})), qt.DeepEquals, []string{"missing-recipe", ruleAnalysisError})
+
+	var b bytes.Buffer
+	c.Assert(writeJUnit(&b, []*roleReport{r}, time.Second), qt.IsNil)
+	c.Assert(b.String(), qt.Contains, `<error message="web: unable to load &#34;vault&#34; dependencies`)
+	c.Assert(b.String(), qt.Contains, `<failure message="1 linting errors found" type="missing-recipe">`)
+}
+
+func TestLintRuleLevels(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"roles/web.json":                   {Data: []byte(`{"name": "website", "run_list": ["recipe[app]", "recipe[app]"]}`)},
+		"roles/db.json":                    {Data: []byte(`{"name": "db", "json_class": "Chef::Role", "chef_type": "role", "run_list": ["recipe[app::missing]"]}`)},
+		"cookbooks/app/metadata.rb":        {Data: []byte("name 'app'\n")},
+		"cookbooks/app/recipes/default.rb": {Data: []byte("")},
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		failed   bool
+		rules    []string
+		expected []string
+	}{
+		{
+			"it should only warn about role metadata",
+			"roles/web.json",
+			false,
+			[]string{"role-name-mismatch", "role-json-class", "role-chef-type", "duplicate-run-list-entry"},
+			[]string{levelWarning, levelWarning, levelWarning, levelWarning},
+		},
+		{
+			"it should fail on run list entries referencing what doesn't exist",
+			"roles/db.json",
+			true,
+			[]string{"missing-recipe"},
+			[]string{levelError},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := qt.New(t)
+
+			r := lintTestRole(c, fsys, tt.path)
+			c.Assert(r.failed(), qt.Equals, tt.failed)
+
+			findings := lintFindings([]*roleReport{r})
+			c.Assert(findingRules(findings), qt.DeepEquals, tt.rules)
+
+			var levels []string
+			for _, f := range findings {
+				levels = append(levels, f.level)
+			}
+			c.Assert(levels, qt.DeepEquals, tt.expected)
+		})
+	}
+}
+
+func TestLintTransitiveMissingCookbooks(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+
+	fsys := fstest.MapFS{
+		"roles/web.json":              {Data: []byte(`{"name": "web", "json_class": "Chef::Role", "chef_type": "role", "run_list": ["recipe[app]"]}`)},
+		"cookbooks/app/metadata.rb":   {Data: []byte("name 'app'\ndepends 'nginx'\n")},
+		"cookbooks/nginx/metadata.rb": {Data: []byte("name 'nginx'\ndepends 'vault'\n")},
+	}
+
+	r := lintTestRole(c, fsys, "roles/web.json", whisk.WithAllowMissing())
+	c.Assert(r.err, qt.IsNil)
+	c.Assert(r.failed(), qt.IsTrue)
+	c.Assert(r.violations, qt.DeepEquals, []whisk.Violation{{
+		Rule:     whisk.RuleMissingCookbook,
+		Role:     "web",
+		Path:     "roles/web.json",
+		Message:  `cookbook "vault" wasn't found in any cookbook path, required by nginx`,
+		Cookbook: "vault",
+	}})
+	c.Assert(findingRules(lintFindings([]*roleReport{r})), qt.DeepEquals, []string{whisk.RuleMissingCookbook})
+}
+
+func TestLintMissingCookbooksOnce(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+
+	fsys := fstest.MapFS{
+		"roles/web.json":            {Data: []byte(`{"name": "web", "json_class": "Chef::Role", "chef_type": "role", "run_list": ["recipe[app]", "recipe[vault]"]}`)},
+		"cookbooks/app/metadata.rb": {Data: []byte("name 'app'\ndepends 'vault'\n")},
+	}
+
+	r := lintTestRole(c, fsys, "roles/web.json", whisk.WithAllowMissing())
+	c.Assert(r.err, qt.IsNil)
+	c.Assert(r.violations, qt.HasLen, 1)
+	c.Assert(r.violations[0].Message, qt.Equals, `"recipe[vault]" references cookbook "vault", which wasn't found in ["cookbooks"]`)
+}
+
+func TestLintFindingsThresholds(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"roles/web.json":          {Data: []byte(`{"name": "web", "json_class": "Chef::Role", "chef_type": "role", "run_list": ["recipe[b]"]}`)},
+		"roles/db.json":           {Data: []byte(`{"name": "db", "json_class": "Chef::Role", "chef_type": "role", "run_list": ["recipe[b]", "recipe[d]"]}`)},
+		"cookbooks/b/metadata.rb": {Data: []byte("name 'b'\ndepends 'c'\n")},
+		"cookbooks/c/metadata.rb": {Data: []byte("name 'c'\ndepends 'b'\n")},
+		"cookbooks/d/metadata.rb": {Data: []byte("name 'd'\ndepends 'e'\n")},
+		"cookbooks/e/metadata.rb": {Data: []byte("name 'e'\ndepends 'd'\n")},
+	}
+
+	tests := []struct {
+		name      string
+		maxCycles uint
+		maxSCCs   uint
+		failed    bool
+		expected  []string
+	}{
+		{
+			"it should warn about cycles and components within thresholds",
+			2,
+			2,
+			false,
+			[]string{
+				"warning circular-dependency: Circular dependency b -> c -> b, found in roles: db, web",
+				"warning circular-dependency: Circular dependency d -> e -> d, found in roles: db",
+				"warning strongly-connected-component: Strongly connected component of 2 cookbooks: b, c, found in roles: db, web",
+				"warning strongly-connected-component: Strongly connected component of 2 cookbooks: d, e, found in roles: db",
+			},
+		},
+		{
+			"it should report cycles exceeding thresholds in any role as errors",
+			1,
+			2,
+			true,
+			[]string{
+				"error max-cycles: Circular dependency b -> c -> b, found in roles: db, web",
+				"error max-cycles: Circular dependency d -> e -> d, found in roles: db",
+				"warning strongly-connected-component: Strongly connected component of 2 cookbooks: b, c, found in roles: db, web",
+				"warning strongly-connected-component: Strongly connected component of 2 cookbooks: d, e, found in roles: db",
+			},
+		},
+		{
+			"it should report components exceeding thresholds as errors",
+			2,
+			1,
+			true,
+			[]string{
+				"error max-sccs: Strongly connected component of 2 cookbooks: b, c, found in roles: db, web",
+				"error max-sccs: Strongly connected component of 2 cookbooks: d, e, found in roles: db",
+				"warning circular-dependency: Circular dependency b -> c -> b, found in roles: db, web",
+				"warning circular-dependency: Circular dependency d -> e -> d, found in roles: db",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := qt.New(t)
+
+			l := newTestLinter(fsys)
+			l.maxCycles, l.maxSCCs, l.maxCookbooksPerSCC = tt.maxCycles, tt.maxSCCs, 2
+
+			var reports []*roleReport
+			failed := false
+			for _, path := range []string{"roles/db.json", "roles/web.json"} {
+				role, err := chef.NewRoleFS(fsys, path)
+				c.Assert(err, qt.IsNil)
+
+				r, err := l.lint(role)
+				c.Assert(err, qt.IsNil)
+				failed = failed || r.failed()
+				reports = append(reports, r)
+			}
+			c.Assert(failed, qt.Equals, tt.failed)
+
+			var got []string
+			for _, f := range lintFindings(reports) {
+				got = append(got, fmt.Sprintf("%s %s: %s", f.level, f.rule, f.message))
+			}
+			sort.Strings(got)
+			c.Assert(got, qt.DeepEquals, tt.expected)
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ndjsonCounts holds the number of cookbooks, strongly connected components, cycles, linting
+// errors and warnings found in a role.
+type ndjsonCounts struct {
+	Cookbooks  int `json:"cookbooks"`
+	SCCs       int `json:"sccs"`
+	Cycles     int `json:"cycles"`
+	Violations int `json:"violations"`
+	Breaches   int `json:"breaches"`
+	Warnings   int `json:"warnings"`
+}
+
+// ndjsonRecord is the linting result of a single role, as written on its own line.
+type ndjsonRecord struct {
+	// Role is the role's name.
+	Role string `json:"role"`
+	// Path is the path of the role's file.
+	Path string `json:"path"`
+	// Passed is whether the role passed linting.
+	Passed bool `json:"passed"`
+	// Counts holds what was found in the role.
+	Counts ndjsonCounts `json:"counts"`
+	// Cycles are the distinct cycles found.
+	Cycles [][]string `json:"cycles"`
+	// Errors are the linting errors found, in the order they are reported.
+	Errors []string `json:"errors"`
+	// Warnings are the role validation violations found that don't fail linting.
+	Warnings []string `json:"warnings"`
+	// DurationSeconds is how long linting the role took.
+	DurationSeconds float64 `json:"duration_seconds"`
+}
+
+// ndjsonWriter writes linting reports as newline delimited JSON, one role per line, as soon as every
+// role is linted. It's safe for concurrent use.
+type ndjsonWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// newNDJSONWriter initializes and returns a ndjsonWriter writing to w.
+func newNDJSONWriter(w io.Writer) *ndjsonWriter {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	return &ndjsonWriter{enc: enc}
+}
+
+// write writes the role's linting report on its own line.
+func (n *ndjsonWriter) write(r *roleReport) error {
+	rec := ndjsonRecord{
+		Role:   r.role.Name,
+		Path:   r.role.Path,
+		Passed: !r.failed(),
+		Counts: ndjsonCounts{
+			Cookbooks:  len(r.result.G),
+			SCCs:       len(r.result.Sccs),
+			Cycles:     len(r.result.Cycles),
+			Violations: len(r.violations),
+			Breaches:   len(r.breaches),
+			Warnings:   len(r.warnings),
+		},
+		Cycles:          r.result.Cycles,
+		Errors:          []string{},
+		Warnings:        []string{},
+		DurationSeconds: r.elapsed.Seconds(),
+	}
+
+	if rec.Cycles == nil {
+		rec.Cycles = [][]string{}
+	}
+
+	for _, err := range r.errors() {
+		rec.Errors = append(rec.Errors, err.Error())
+	}
+
+	for _, w := range r.warnings {
+		rec.Warnings = append(rec.Warnings, w.String())
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if err := n.enc.Encode(rec); err != nil {
+		return fmt.Errorf("failed writing %s linting results: %w", r.role.Name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"text/tabwriter"
+
+	"slack/whisk"
+	"slack/whisk/chef"
+
+	"github.com/spf13/cobra"
+	"github.com/xlab/treeprint"
+)
+
+var nodeCmd = &cobra.Command{
+	Use:   "node [flags] <node_path|nodes_dir>",
+	Short: "Analyzes the dependency graph of Chef nodes, as exported by knife node show -F json",
+	Long: `Expands a node's run list through the roles directory and analyzes the resulting dependency graph,
+the same way roles are analyzed. When given a directory of node exports, every node is analyzed
+separately and a summary is reported, listing which nodes are affected by cycles.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("a node file path or nodes directory is required")
+		}
+
+		return nil
+	},
+	RunE: node,
+}
+
+// nodeFormat is the output format of the node subcommand.
+var nodeFormat string
+
+// init Initializes command line flags supported.
+func init() {
+	flagSet := nodeCmd.Flags()
+	addDOTFlags(nodeCmd)
+	flagSet.StringVarP(&nodeFormat, "output", "o", "ascii", "Output format, either ascii, json, dot, svg, html, graphml, gexf, mermaid, plantuml, runlist, github or gitlab-codequality. Only ascii and json are supported for nodes directories")
+}
+
+// nodeSummary is the result of analyzing a single node from a directory of node exports.
+type nodeSummary struct {
+	// Node is the node's name.
+	Node string
+	// Path is the node export's path.
+	Path string
+	// Cookbooks is the number of cookbooks in the node's dependency graph.
+	Cookbooks int
+	// Sccs is the number of strongly connected components found.
+	Sccs int
+	// Cycles is the number of distinct cycles found.
+	Cycles int
+}
+
+// nodeDocument returns the analysis of the node stored in nodePath as a versioned JSON document.
+func nodeDocument(handler *whisk.Handler, n *chef.Node, nodePath string) whisk.Document {
+	doc := handler.Document()
+	doc.Node = &whisk.DocumentNode{Name: n.Name, Path: nodePath}
+
+	return doc
+}
+
+// node is a Cobra function handler for the node subcommand.
+func node(cmd *cobra.Command, args []string) error {
+	roles := rolesPath
+	if roles == "" {
+		roles = "./roles"
+	}
+
+	fsys, paths, done, err := openSource(append(strings.Split(cookbookPath, ","), roles, args[0]))
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	cookbooks, rolesPath, nodePath := paths[:len(paths)-2], paths[len(paths)-2], paths[len(paths)-1]
+
+	info, err := fs.Stat(fsys, nodePath)
+	if err != nil {
+		return fmt.Errorf("failed reading %q: %w", args[0], err)
+	}
+
+	if !info.IsDir() {
+		tree := treeprint.New()
+
+		handler, n, err := analyzeNode(fsys, cookbooks, rolesPath, nodePath, tree)
+		if err != nil {
+			return err
+		}
+
+		if nodeFormat == "json" {
+			if err := nodeDocument(handler, n, nodePath).Encode(os.Stdout); err != nil {
+				return fmt.Errorf("failed to encode graph to JSON: %w", err)
+			}
+
+			return nil
+		}
+
+		return render(handler, tree, nodeFormat)
+	}
+
+	entries, err := fs.ReadDir(fsys, nodePath)
+	if err != nil {
+		return fmt.Errorf("failed reading nodes directory: %w", err)
+	}
+
+	var summaries []nodeSummary
+	docs := []whisk.Document{}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+
+		p := path.Join(nodePath, e.Name())
+		handler, n, err := analyzeNode(fsys, cookbooks, rolesPath, p, treeprint.New())
+		if err != nil {
+			return err
+		}
+
+		if nodeFormat == "json" {
+			docs = append(docs, nodeDocument(handler, n, p))
+			continue
+		}
+
+		r := handler.Result()
+		summaries = append(summaries, nodeSummary{
+			Node:      n.Name,
+			Path:      p,
+			Cookbooks: len(r.G),
+			Sccs:      len(r.Sccs),
+			Cycles:    len(r.Cycles),
+		})
+	}
+
+	if nodeFormat == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+
+		if err := enc.Encode(docs); err != nil {
+			return fmt.Errorf("failed to encode nodes to JSON: %w", err)
+		}
+
+		return nil
+	}
+
+	affected := 0
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "node\tcookbooks\tsccs\tcycles\t")
+	for _, s := range summaries {
+		if s.Cycles > 0 {
+			affected++
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t\n", s.Node, s.Cookbooks, s.Sccs, s.Cycles)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stdout, "\n🌀 Nodes affected by cycles: %d of %d\n", affected, len(summaries))
+
+	return nil
+}
+
+// analyzeNode expands the run list of the node stored in nodePath through the roles in rolesPath,
+// loading its dependency graph, and finds its strongly connected components and distinct cycles.
+func analyzeNode(fsys fs.FS, cookbooks []string, rolesPath, nodePath string, tree treeprint.Tree) (*whisk.Handler, *chef.Node, error) {
+	n, err := chef.NewNodeFS(fsys, nodePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed loading node: %w", err)
+	}
+
+	handler := whisk.NewHandler(cookbooks, rolesPath, handlerOptions(fsys)...)
+	if err := handler.WalkRunList(n.RunList, tree); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", n.Name, err)
+	}
+
+	// Violations are only reported along with the graph, so failing to validate roles doesn't prevent rendering it.
+	if err := handler.ValidateRoles(); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %s: failed validating roles: %s\n", n.Name, err)
+	}
+
+	if err := handler.FindSCCs(); err != nil {
+		return nil, nil, fmt.Errorf("%s: failed to find strongly connected components: %w", n.Name, err)
+	}
+
+	if err := handler.FindCycles(); err != nil {
+		return nil, nil, fmt.Errorf("%s: failed to enumerate distinct cyles: %w", n.Name, err)
+	}
+
+	return handler, n, nil
+}
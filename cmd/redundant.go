@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/xlab/treeprint"
+)
+
+var redundantCmd = &cobra.Command{
+	Use:   "redundant [flags] <role_path>",
+	Short: "Lists cookbook dependencies that are already implied by other dependencies",
+	Long: `Computes the transitive reduction of the role's dependency graph, once strongly connected
+components are condensed, and lists the "depends" entries that could be removed from cookbooks
+metadata without changing which cookbooks are reachable from each other.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("a role file path is required")
+		}
+
+		return nil
+	},
+	RunE: redundant,
+}
+
+// redundant is a Cobra function handler for the redundant subcommand.
+func redundant(cmd *cobra.Command, args []string) error {
+	handler, err := analyzeRoleArg(args[0], treeprint.New())
+	if err != nil {
+		return err
+	}
+
+	if err := handler.FindRedundantDeps(); err != nil {
+		return fmt.Errorf("failed to reduce dependency graph: %w", err)
+	}
+
+	r := handler.Result()
+	fmt.Fprintf(os.Stdout, "\n✂️  Redundant dependencies: %d\n\n", len(r.Redundant))
+	if len(r.Redundant) == 0 {
+		fmt.Fprintf(os.Stdout, "None! 🍻 🎉 \n\n")
+	}
+
+	for i, e := range r.Redundant {
+		i++
+		fmt.Fprintf(os.Stdout, "%d. %s -> %s\n", i, e[0], e[1])
+	}
+
+	return nil
+}
@@ -3,12 +3,18 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"slack/whisk"
+	"slack/whisk/artifact"
 	"slack/whisk/chef"
+	"slack/whisk/chefserver"
+	"slack/whisk/gitfs"
 
 	"github.com/spf13/cobra"
 	"github.com/xlab/treeprint"
@@ -36,55 +42,305 @@ var rootCmd = &cobra.Command{
 
 var (
 	cookbookPath string
+	rolesPath    string
 	outputFormat string
+	revision     string
+	source       string
+	clientName   string
+	clientKey    string
+	include      []string
+	exclude      []string
+	allowMissing bool
+	focus        string
+	cluster      string
+	reduce       bool
 )
 
 // Execute parses CLI flags and arguments and runs the CLI command.
 func Execute() error {
-	rootCmd.PersistentFlags().StringVarP(&cookbookPath, "cookbook-path", "c", "./cookbooks", "Comma-separated cookbook paths")
-	rootCmd.Flags().StringVarP(&outputFormat, "output", "o", "ascii", "Output format, either ascii, json or dot")
+	rootCmd.PersistentFlags().StringVarP(&cookbookPath, "cookbook-path", "c", "./cookbooks", "Comma-separated cookbook paths, .tgz cookbook artifacts or chef export directories")
+	rootCmd.PersistentFlags().StringVarP(&rolesPath, "roles-path", "r", "", "Directory where Chef roles are stored, defaults to the closest roles directory above the role file, or ./roles for nodes")
+	rootCmd.PersistentFlags().StringVar(&revision, "rev", "", "Git revision of the chef-repo in the current directory to read roles and cookbooks from, instead of the working tree")
+	rootCmd.PersistentFlags().StringVar(&source, "source", "", "Where to read roles and cookbooks from instead of the local filesystem, like chef-server=<organization_url>")
+	rootCmd.PersistentFlags().StringVar(&clientName, "client-name", "", "Chef Server client name used to sign requests")
+	rootCmd.PersistentFlags().StringVar(&clientKey, "client-key", "", "Path to the Chef Server client's private key")
+	rootCmd.PersistentFlags().StringSliceVar(&include, "include", nil, "Glob patterns of the role files to load from the roles directory and its subdirectories, relative to it")
+	rootCmd.PersistentFlags().BoolVar(&allowMissing, "allow-missing", false, "Record cookbooks not found in any cookbook path as missing and carry on, instead of failing")
+	rootCmd.PersistentFlags().StringSliceVar(&exclude, "exclude", nil, "Glob patterns of the role files and directories to skip in the roles directory, relative to it")
+	addDOTFlags(rootCmd)
+	rootCmd.Flags().BoolVar(&reduce, "reduce", false, "Leave dependencies implied by other dependencies out of the dot, svg, mermaid and plantuml diagrams, and list them in the json output format")
+	rootCmd.Flags().StringVarP(&outputFormat, "output", "o", "ascii", "Output format, either ascii, json, dot, svg, html, graphml, gexf, mermaid, plantuml, runlist, github or gitlab-codequality")
 
 	// Add subcommands to the root command here
+	rootCmd.AddCommand(berksCmd)
+	rootCmd.AddCommand(diffCmd)
 	rootCmd.AddCommand(lintCmd)
+	rootCmd.AddCommand(nodeCmd)
+	rootCmd.AddCommand(redundantCmd)
+	rootCmd.AddCommand(schemaCmd)
+	rootCmd.AddCommand(statsCmd)
 
 	return rootCmd.Execute()
 }
 
 func root(cmd *cobra.Command, args []string) error {
-	rolePath := args[0]
-
 	tree := treeprint.New()
-	handler := whisk.NewHandler(strings.Split(cookbookPath, ","), filepath.Dir(rolePath))
 
-	role, err := chef.NewRole(rolePath)
+	handler, err := analyzeRoleArg(args[0], tree)
 	if err != nil {
-		return fmt.Errorf("failed loading role: %w", err)
-	}
-
-	if err := handler.WalkRole(role.Name, tree); err != nil {
-		return fmt.Errorf("%w", err)
+		return err
 	}
 
-	if err := handler.FindSCCs(); err != nil {
-		return fmt.Errorf("failed to find strongly connected components: %w", err)
+	if reduce {
+		if err := handler.FindRedundantDeps(); err != nil {
+			return fmt.Errorf("failed to reduce dependency graph: %w", err)
+		}
 	}
 
-	if err := handler.FindCycles(); err != nil {
-		return fmt.Errorf("failed to enumerate distinct cyles: %w", err)
-	}
+	return render(handler, tree, outputFormat)
+}
 
-	switch outputFormat {
+// render writes the dependency analysis results to stdout in the given output format.
+func render(handler *whisk.Handler, tree treeprint.Tree, format string) error {
+	switch format {
 	case "json":
 		if err := handler.JSON(os.Stdout); err != nil {
 			return fmt.Errorf("failed to encode graph to JSON: %w", err)
 		}
 	case "dot":
-		if err := handler.DOT(os.Stdout); err != nil {
+		opts, err := dotOptions()
+		if err != nil {
+			return err
+		}
+		if err := handler.DOT(os.Stdout, opts...); err != nil {
 			return fmt.Errorf("failed to encode graph to DOT: %w", err)
 		}
+	case "svg":
+		if err := handler.SVG(os.Stdout); err != nil {
+			return err
+		}
+	case "html":
+		if err := handler.HTML(tree, os.Stdout); err != nil {
+			return err
+		}
+	case "graphml":
+		if err := handler.GraphML(os.Stdout); err != nil {
+			return fmt.Errorf("failed to encode graph to GraphML: %w", err)
+		}
+	case "gexf":
+		if err := handler.GEXF(os.Stdout); err != nil {
+			return fmt.Errorf("failed to encode graph to GEXF: %w", err)
+		}
+	case "mermaid":
+		if err := handler.Mermaid(os.Stdout); err != nil {
+			return err
+		}
+	case "plantuml":
+		if err := handler.PlantUML(os.Stdout); err != nil {
+			return err
+		}
+	case "runlist":
+		if err := handler.RunList(os.Stdout); err != nil {
+			return err
+		}
+	case "github":
+		if err := writeGitHubAnnotations(os.Stdout, handlerFindings(handler)); err != nil {
+			return fmt.Errorf("failed writing GitHub annotations: %w", err)
+		}
+	case "gitlab-codequality":
+		if err := writeCodeQuality(os.Stdout, handlerFindings(handler)); err != nil {
+			return fmt.Errorf("failed writing Code Quality report: %w", err)
+		}
 	default:
 		handler.ASCII(tree, os.Stdout)
 	}
 
 	return nil
 }
+
+// analyzeRoleArg analyzes the role stored in rolePath, honoring the --cookbook-path, --roles-path
+// and --rev flags.
+func analyzeRoleArg(rolePath string, tree treeprint.Tree) (*whisk.Handler, error) {
+	fsys, paths, done, err := openSource(append(strings.Split(cookbookPath, ","), rolesRoot(rolePath), rolePath))
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	return analyzeRole(fsys, paths[:len(paths)-2], paths[len(paths)-2], paths[len(paths)-1], tree)
+}
+
+// rolesRoot returns the directory the roles referenced by the role stored in rolePath are looked up in:
+// the --roles-path flag when set, otherwise the closest ancestor directory named roles, as found in
+// chef-repos, so roles in subdirectories can reference any other role. Roles stored anywhere else
+// are looked up in their own directory.
+func rolesRoot(rolePath string) string {
+	if rolesPath != "" {
+		return rolesPath
+	}
+
+	for dir := filepath.Dir(rolePath); ; dir = filepath.Dir(dir) {
+		if filepath.Base(dir) == "roles" {
+			return dir
+		}
+
+		if parent := filepath.Dir(dir); parent == dir {
+			break
+		}
+	}
+
+	return filepath.Dir(rolePath)
+}
+
+// handlerOptions returns the whisk handler options for fsys and the global flags given.
+func handlerOptions(fsys fs.FS) []whisk.Option {
+	opts := []whisk.Option{whisk.WithFS(fsys), whisk.WithRoleFilters(include, exclude)}
+	if allowMissing {
+		opts = append(opts, whisk.WithAllowMissing())
+	}
+
+	return opts
+}
+
+// addDOTFlags adds the flags configuring the dot output format to the command.
+func addDOTFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&focus, "focus", "", "Render only the strongly connected component with the given id, numbered from 0 as in the ascii and json output formats, with the dot output format, like scc:0")
+	cmd.Flags().StringVar(&cluster, "cluster", "scc", "How to cluster cookbooks with the dot output format, either scc or path")
+}
+
+// dotOptions returns the DOT rendering options set by the --focus and --cluster flags.
+func dotOptions() ([]whisk.DOTOption, error) {
+	var opts []whisk.DOTOption
+	if focus != "" {
+		i, err := strconv.Atoi(strings.TrimPrefix(focus, "scc:"))
+		if err != nil || !strings.HasPrefix(focus, "scc:") {
+			return nil, fmt.Errorf("invalid --focus %q, expected scc:<index>", focus)
+		}
+		opts = append(opts, whisk.FocusSCC(i))
+	}
+
+	switch cluster {
+	case "scc":
+	case "path":
+		opts = append(opts, whisk.ClusterByPath())
+	default:
+		return nil, fmt.Errorf("invalid --cluster %q, expected scc or path", cluster)
+	}
+
+	return opts, nil
+}
+
+// analyzeRole walks the role stored in rolePath, looking up the roles it references in rolesPath and
+// loading its dependency graph from the given cookbook paths of fsys, and finds its strongly connected
+// components and distinct cycles.
+func analyzeRole(fsys fs.FS, cookbooks []string, rolesPath, rolePath string, tree treeprint.Tree) (*whisk.Handler, error) {
+	handler := whisk.NewHandler(cookbooks, rolesPath, handlerOptions(fsys)...)
+
+	role, err := chef.NewRoleFS(fsys, rolePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed loading role: %w", err)
+	}
+
+	if err := handler.WalkRole(role.Name, tree); err != nil {
+		return nil, fmt.Errorf("failed walking role %q: %w", role.Name, err)
+	}
+
+	// Violations are only reported along with the graph, so failing to validate roles doesn't prevent rendering it.
+	if err := handler.ValidateRoles(); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed validating roles: %s\n", err)
+	}
+
+	if err := handler.FindSCCs(); err != nil {
+		return nil, fmt.Errorf("failed to find strongly connected components: %w", err)
+	}
+
+	if err := handler.FindCycles(); err != nil {
+		return nil, fmt.Errorf("failed to enumerate distinct cyles: %w", err)
+	}
+
+	return handler, nil
+}
+
+// openSource returns the filesystem to load roles and cookbooks from, along with the given
+// local paths translated into it. When the --rev flag is set, it is the git revision of the
+// repository in the current working directory; when --source is set, it is the Chef Server
+// given; otherwise it is the local filesystem. Paths pointing to cookbook artifacts are served
+// with the cookbooks they contain. Callers must call done once they are finished with the
+// filesystem.
+func openSource(paths []string) (fsys fs.FS, translated []string, done func(), err error) {
+	if revision != "" && source != "" {
+		return nil, nil, nil, errors.New("--rev and --source can't be used together")
+	}
+
+	if revision != "" {
+		return openRevision(revision, paths)
+	}
+
+	if source != "" {
+		return openChefServer(source, paths)
+	}
+
+	fsys, err = artifact.Mount(chef.LocalFS, paths)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed opening cookbook artifacts: %w", err)
+	}
+
+	return fsys, paths, func() {}, nil
+}
+
+// openRevision opens a git revision of the repository in the current working directory,
+// translating the given local paths into it and serving cookbook artifacts found in them.
+func openRevision(rev string, paths []string) (fs.FS, []string, func(), error) {
+	g, err := gitfs.New(".", rev)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed opening git revision: %w", err)
+	}
+
+	translated := make([]string, 0, len(paths))
+	for _, p := range paths {
+		t, err := g.Path(p)
+		if err != nil {
+			g.Close()
+			return nil, nil, nil, err
+		}
+		translated = append(translated, t)
+	}
+
+	fsys, err := artifact.Mount(g, translated)
+	if err != nil {
+		g.Close()
+		return nil, nil, nil, fmt.Errorf("failed opening cookbook artifacts: %w", err)
+	}
+
+	return fsys, translated, func() { g.Close() }, nil
+}
+
+// openChefServer opens the Chef Server given as chef-server=<organization_url>, where roles and
+// cookbooks are served as roles/<role_name>.json and cookbooks/<cookbook_name>/metadata.json.
+func openChefServer(source string, paths []string) (fs.FS, []string, func(), error) {
+	kind, serverURL, _ := strings.Cut(source, "=")
+	if kind != "chef-server" || serverURL == "" {
+		return nil, nil, nil, fmt.Errorf("unsupported source %q, expected chef-server=<organization_url>", source)
+	}
+
+	if clientName == "" || clientKey == "" {
+		return nil, nil, nil, errors.New("--client-name and --client-key are required to read from a Chef Server")
+	}
+
+	keyPEM, err := os.ReadFile(clientKey)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed reading client key: %w", err)
+	}
+
+	client, err := chefserver.NewClient(serverURL, clientName, keyPEM)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed creating Chef Server client: %w", err)
+	}
+
+	translated := make([]string, 0, len(paths))
+	for _, p := range paths {
+		translated = append(translated, path.Clean(filepath.ToSlash(p)))
+	}
+
+	return chefserver.NewFS(client), translated, func() {}, nil
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"bytes"
+	"io/fs"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/xlab/treeprint"
+
+	"slack/whisk"
+)
+
+// failingFS is a filesystem failing to open or stat the given names with fs.ErrPermission.
+type failingFS struct {
+	fstest.MapFS
+	fail map[string]bool
+}
+
+// Open opens the named file, unless it's set to fail.
+func (f failingFS) Open(name string) (fs.File, error) {
+	if f.fail[name] {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+
+	return f.MapFS.Open(name)
+}
+
+// Stat returns information about the named file, unless it's set to fail.
+func (f failingFS) Stat(name string) (fs.FileInfo, error) {
+	if f.fail[name] {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrPermission}
+	}
+
+	return f.MapFS.Stat(name)
+}
+
+func TestAnalyzeValidationErrors(t *testing.T) {
+	t.Parallel()
+
+	// app's recipes can't be read, so roles can't be validated, while its metadata can be analyzed.
+	fsys := failingFS{
+		MapFS: fstest.MapFS{
+			"roles/web.json":                   {Data: []byte(`{"name": "web", "run_list": ["recipe[app]"]}`)},
+			"nodes/web-1.json":                 {Data: []byte(`{"name": "web-1", "run_list": ["role[web]"]}`)},
+			"cookbooks/app/metadata.rb":        {Data: []byte("name 'app'\ndepends 'ntp'\n")},
+			"cookbooks/app/recipes/default.rb": {Data: []byte("")},
+			"cookbooks/ntp/metadata.rb":        {Data: []byte("name 'ntp'\n")},
+		},
+		fail: map[string]bool{"cookbooks/app/recipes": true},
+	}
+
+	tests := []struct {
+		name    string
+		analyze func() (*whisk.Handler, error)
+	}{
+		{
+			"it should render roles",
+			func() (*whisk.Handler, error) {
+				return analyzeRole(fsys, []string{"cookbooks"}, "roles", "roles/web.json", treeprint.New())
+			},
+		},
+		{
+			"it should render nodes",
+			func() (*whisk.Handler, error) {
+				h, _, err := analyzeNode(fsys, []string{"cookbooks"}, "roles", "nodes/web-1.json", treeprint.New())
+				return h, err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := qt.New(t)
+
+			h, err := tt.analyze()
+			c.Assert(err, qt.IsNil)
+
+			var b bytes.Buffer
+			c.Assert(h.DOT(&b), qt.IsNil)
+			c.Assert(b.String(), qt.Contains, `"app" -> "ntp";`)
+		})
+	}
+}
+
+func TestRolesRoot(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		rolePath string
+		expected string
+	}{
+		{"it should look roles up in the roles directory", "roles/web.json", "roles"},
+		{"it should look roles in subdirectories up in the roles directory", "roles/prod/web.json", "roles"},
+		{"it should find the closest roles directory", "/src/chef-repo/roles/prod/eu/web.json", "/src/chef-repo/roles"},
+		{"it should fall back to the role's directory", "site-roles/prod/web.json", "site-roles/prod"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			qt.Assert(t, rolesRoot(tt.rolePath), qt.Equals, filepath.FromSlash(tt.expected))
+		})
+	}
+}
+
+func TestAnalyzeNestedRole(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+
+	fsys := fstest.MapFS{
+		"roles/base.json":           {Data: []byte(`{"name": "base", "run_list": ["recipe[ntp]"]}`)},
+		"roles/prod/web.json":       {Data: []byte(`{"name": "web", "run_list": ["role[base]", "recipe[app]"]}`)},
+		"cookbooks/app/metadata.rb": {Data: []byte("name 'app'\n")},
+		"cookbooks/ntp/metadata.rb": {Data: []byte("name 'ntp'\n")},
+	}
+
+	h, err := analyzeRole(fsys, []string{"cookbooks"}, rolesRoot("roles/prod/web.json"), "roles/prod/web.json", treeprint.New())
+	c.Assert(err, qt.IsNil)
+	c.Assert(h.Document().RunList, qt.DeepEquals, []string{"role[web]"})
+	c.Assert(h.Document().Roles, qt.HasLen, 2)
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"io"
+	"sort"
+
+	"slack/whisk/sarif"
+)
+
+// writeSARIF writes the findings as a SARIF log.
+func writeSARIF(w io.Writer, findings []finding) error {
+	ids := make([]string, 0, len(ruleDescriptions))
+	for id := range ruleDescriptions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	driver := sarif.Driver{Name: "whisk", InformationURI: "https://slack-github.com/slack/goslackgo/tree/master/whisk"}
+	for _, id := range ids {
+		driver.Rules = append(driver.Rules, sarif.Rule{ID: id, ShortDescription: sarif.Message{Text: ruleDescriptions[id]}})
+	}
+
+	log := sarif.New(driver)
+	for _, f := range findings {
+		result := sarif.Result{
+			RuleID:    f.rule,
+			Level:     f.level,
+			Message:   sarif.Message{Text: f.message},
+			Locations: []sarif.Location{sarifLocation(f.location)},
+		}
+
+		for i, l := range f.related {
+			loc := sarifLocation(l)
+			// related locations require ids.
+			loc.ID = i + 1
+			result.RelatedLocations = append(result.RelatedLocations, loc)
+		}
+
+		log.AddResult(result)
+	}
+
+	return log.Encode(w)
+}
+
+// sarifLocation converts a location into a SARIF one.
+func sarifLocation(l location) sarif.Location {
+	return sarif.NewLocation(repoPath(l.file), l.line, l.message)
+}
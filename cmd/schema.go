@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"slack/whisk"
+
+	"github.com/spf13/cobra"
+)
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Prints the JSON Schema describing the json output format",
+	Long: `Prints the JSON Schema describing the versioned document written by whisk -o json, whisk node -o json
+and whisk berks -o json, to validate it or generate bindings from.`,
+	Args: cobra.NoArgs,
+	RunE: schema,
+}
+
+// schema is a Cobra function handler for the schema subcommand.
+func schema(cmd *cobra.Command, args []string) error {
+	if _, err := os.Stdout.Write(whisk.Schema); err != nil {
+		return fmt.Errorf("failed writing JSON Schema: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"slack/whisk"
+
+	"github.com/spf13/cobra"
+	"github.com/xlab/treeprint"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats [flags] <role_path|roles_dir>",
+	Short: "Ranks cookbooks by cycle participation, degree, reachability, depth and centrality",
+	Long: `Computes graph metrics for every cookbook in the dependency graph of a single role or, when
+given a roles directory, of all roles combined. Cookbooks are ranked by the number of cycles they
+participate in, followed by their betweenness centrality, to help prioritize refactoring.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("a role file path or roles directory is required")
+		}
+
+		return nil
+	},
+	RunE: stats,
+}
+
+// Command line flags for the stats subcommand.
+var (
+	statsTop    uint
+	statsFormat string
+)
+
+// init Initializes command line flags supported.
+func init() {
+	flagSet := statsCmd.Flags()
+	flagSet.UintVar(&statsTop, "top", 20, "number of cookbooks to display, 0 displays all of them")
+	flagSet.StringVarP(&statsFormat, "output", "o", "ascii", "Output format, either ascii or json")
+}
+
+// stats is a Cobra function handler for the stats subcommand.
+func stats(cmd *cobra.Command, args []string) error {
+	fsys, paths, done, err := openSource(append(strings.Split(cookbookPath, ","), rolesRoot(args[0]), args[0]))
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	cookbooks, roles, path := paths[:len(paths)-2], paths[len(paths)-2], paths[len(paths)-1]
+
+	info, err := fs.Stat(fsys, path)
+	if err != nil {
+		return fmt.Errorf("failed reading %q: %w", args[0], err)
+	}
+
+	var handler *whisk.Handler
+	if info.IsDir() {
+		handler, err = analyzeRoles(fsys, cookbooks, path)
+	} else {
+		handler, err = analyzeRole(fsys, cookbooks, roles, path, treeprint.New())
+	}
+
+	if err != nil {
+		return err
+	}
+
+	vertices, err := handler.Stats()
+	if err != nil {
+		return fmt.Errorf("failed to compute graph metrics: %w", err)
+	}
+
+	if statsTop > 0 && int(statsTop) < len(vertices) {
+		vertices = vertices[:statsTop]
+	}
+
+	if statsFormat == "json" {
+		if err := json.NewEncoder(os.Stdout).Encode(vertices); err != nil {
+			return fmt.Errorf("failed to encode graph metrics to JSON: %w", err)
+		}
+
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tcookbook\tcycles\tin\tout\tclosure\tdepth\tbetweenness\t")
+	for i, v := range vertices {
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%d\t%d\t%d\t%.2f\t\n",
+			i+1, v.Name, v.Cycles, v.InDegree, v.OutDegree, v.Closure, v.Depth, v.Betweenness)
+	}
+
+	return tw.Flush()
+}
+
+// analyzeRoles walks every role in rolesDir into a single dependency graph, and finds
+// its strongly connected components and distinct cycles.
+func analyzeRoles(fsys fs.FS, cookbooks []string, rolesDir string) (*whisk.Handler, error) {
+	handler := whisk.NewHandler(cookbooks, filepath.Clean(rolesDir), handlerOptions(fsys)...)
+
+	roles, err := handler.Roles()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range roles {
+		if err := handler.WalkRole(name, treeprint.New()); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	if err := handler.FindSCCs(); err != nil {
+		return nil, fmt.Errorf("failed to find strongly connected components: %w", err)
+	}
+
+	if err := handler.FindCycles(); err != nil {
+		return nil, fmt.Errorf("failed to enumerate distinct cyles: %w", err)
+	}
+
+	return handler, nil
+}
@@ -0,0 +1,196 @@
+package whisk
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// cycleColor is the color cycle edges are highlighted with.
+const cycleColor = "#E01E5A"
+
+// diagram holds the dependency graph laid out for diagram renderers: vertices get identifiers safe
+// to use in any diagram language, and are grouped by the strongly connected component they belong to.
+type diagram struct {
+	// vertices are the graph's vertices, sorted alphabetically.
+	vertices []string
+	// ids maps every vertex to its identifier.
+	ids map[string]string
+	// sccs maps every vertex in a strongly connected component to the component's index.
+	sccs map[string]int
+	// edges are the graph's edges in rendering order: by vertex, then by dependency.
+	edges [][2]string
+}
+
+// newDiagram lays out the dependency graph for diagram renderers.
+func (h *Handler) newDiagram() *diagram {
+	d := &diagram{
+		vertices: vertices(h.graph),
+		ids:      make(map[string]string, len(h.graph)),
+		sccs:     make(map[string]int),
+	}
+
+	for i, v := range d.vertices {
+		d.ids[v] = fmt.Sprintf("n%d", i)
+	}
+
+	for i, scc := range h.sccs {
+		for _, v := range scc {
+			d.sccs[v] = i
+		}
+	}
+
+	for _, v := range d.vertices {
+		for _, dep := range h.graph[v] {
+			d.edges = append(d.edges, [2]string{v, dep})
+		}
+	}
+
+	return d
+}
+
+// reduce leaves the redundant dependencies out of the diagram's edges, drawing the transitive
+// reduction of the graph instead.
+func (d *diagram) reduce(redundant [][]string) {
+	if len(redundant) == 0 {
+		return
+	}
+
+	skip := make(map[[2]string]bool, len(redundant))
+	for _, e := range redundant {
+		skip[[2]string{e[0], e[1]}] = true
+	}
+
+	edges := d.edges[:0]
+	for _, e := range d.edges {
+		if !skip[e] {
+			edges = append(edges, e)
+		}
+	}
+	d.edges = edges
+}
+
+// inCycle returns whether the edge is part of any cycle found.
+func (h *Handler) inCycle(e [2]string) bool {
+	return h.participation.Edges[e[0]][e[1]] > 0
+}
+
+// label returns the vertex's label, flagging missing cookbooks.
+func (h *Handler) label(v string) string {
+	if h.missing[v] {
+		return fmt.Sprintf("%s (missing)", v)
+	}
+
+	return v
+}
+
+// sortedSCCVertices returns the vertices of the strongly connected component, sorted alphabetically.
+func sortedSCCVertices(scc []string) []string {
+	sorted := append([]string(nil), scc...)
+	sort.Strings(sorted)
+
+	return sorted
+}
+
+// mermaidLabel escapes quotes, which Mermaid doesn't allow in labels.
+var mermaidLabel = strings.NewReplacer(`"`, "#quot;")
+
+// Mermaid encodes the dependency graph as a Mermaid flowchart, which renders natively in Markdown.
+// Strongly connected components are grouped in subgraphs, and dependencies in cycles are highlighted.
+func (h *Handler) Mermaid(w io.Writer) error {
+	d := h.newDiagram()
+	d.reduce(h.redundant)
+
+	var b strings.Builder
+	fmt.Fprintln(&b, "flowchart TD")
+	for i, scc := range h.sccs {
+		fmt.Fprintf(&b, "    subgraph scc%d [\"Strongly Connected Subgraph %d\"]\n", i, i)
+		for _, v := range sortedSCCVertices(scc) {
+			fmt.Fprintf(&b, "        %s[\"%s\"]\n", d.ids[v], mermaidLabel.Replace(h.label(v)))
+		}
+		fmt.Fprintln(&b, "    end")
+	}
+
+	for _, v := range d.vertices {
+		if _, ok := d.sccs[v]; !ok {
+			fmt.Fprintf(&b, "    %s[\"%s\"]\n", d.ids[v], mermaidLabel.Replace(h.label(v)))
+		}
+	}
+
+	var cycleEdges []string
+	for i, e := range d.edges {
+		fmt.Fprintf(&b, "    %s --> %s\n", d.ids[e[0]], d.ids[e[1]])
+		if h.inCycle(e) {
+			cycleEdges = append(cycleEdges, fmt.Sprint(i))
+		}
+	}
+
+	if len(cycleEdges) > 0 {
+		fmt.Fprintf(&b, "    linkStyle %s stroke:%s,stroke-width:2px\n", strings.Join(cycleEdges, ","), cycleColor)
+	}
+
+	if len(h.missing) > 0 {
+		fmt.Fprintln(&b, "    classDef missing stroke-dasharray: 5 5")
+		for _, v := range d.vertices {
+			if h.missing[v] {
+				fmt.Fprintf(&b, "    class %s missing\n", d.ids[v])
+			}
+		}
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed writing mermaid diagram: %w", err)
+	}
+
+	return nil
+}
+
+// plantUMLLabel escapes quotes, which PlantUML doesn't allow in labels.
+var plantUMLLabel = strings.NewReplacer(`"`, "'")
+
+// PlantUML encodes the dependency graph as a PlantUML diagram. Strongly connected components are
+// grouped in packages, and dependencies in cycles are highlighted.
+func (h *Handler) PlantUML(w io.Writer) error {
+	d := h.newDiagram()
+	d.reduce(h.redundant)
+
+	vertex := func(b *strings.Builder, indent, v string) {
+		fmt.Fprintf(b, "%srectangle \"%s\" as %s", indent, plantUMLLabel.Replace(h.label(v)), d.ids[v])
+		if h.missing[v] {
+			fmt.Fprint(b, " #line.dashed")
+		}
+		fmt.Fprintln(b)
+	}
+
+	var b strings.Builder
+	fmt.Fprintln(&b, "@startuml")
+	for i, scc := range h.sccs {
+		fmt.Fprintf(&b, "package \"Strongly Connected Subgraph %d\" as scc%d #FFF4D6 {\n", i, i)
+		for _, v := range sortedSCCVertices(scc) {
+			vertex(&b, "  ", v)
+		}
+		fmt.Fprintln(&b, "}")
+	}
+
+	for _, v := range d.vertices {
+		if _, ok := d.sccs[v]; !ok {
+			vertex(&b, "", v)
+		}
+	}
+
+	for _, e := range d.edges {
+		if h.inCycle(e) {
+			fmt.Fprintf(&b, "%s -[%s,bold]-> %s\n", d.ids[e[0]], cycleColor, d.ids[e[1]])
+			continue
+		}
+		fmt.Fprintf(&b, "%s --> %s\n", d.ids[e[0]], d.ids[e[1]])
+	}
+	fmt.Fprintln(&b, "@enduml")
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed writing plantuml diagram: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package whisk
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/xlab/treeprint"
+)
+
+func TestHandlerDiagrams(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"roles/web.json":              {Data: []byte(`{"name": "web", "run_list": ["recipe[app]"]}`)},
+		"cookbooks/app/metadata.rb":   {Data: []byte("name 'app'\ndepends 'nginx'\ndepends 'php'\n")},
+		"cookbooks/nginx/metadata.rb": {Data: []byte("name 'nginx'\ndepends 'ssl'\n")},
+		"cookbooks/ssl/metadata.rb":   {Data: []byte("name 'ssl'\ndepends 'nginx'\n")},
+	}
+
+	tests := []struct {
+		name     string
+		render   func(h *Handler, b *bytes.Buffer) error
+		expected string
+	}{
+		{
+			"it should render mermaid flowcharts",
+			func(h *Handler, b *bytes.Buffer) error { return h.Mermaid(b) },
+			`flowchart TD
+    subgraph scc0 ["Strongly Connected Subgraph 0"]
+        n1["nginx"]
+        n3["ssl"]
+    end
+    n0["app"]
+    n2["php (missing)"]
+    n0 --> n1
+    n0 --> n2
+    n1 --> n3
+    n3 --> n1
+    linkStyle 2,3 stroke:#E01E5A,stroke-width:2px
+    classDef missing stroke-dasharray: 5 5
+    class n2 missing
+`,
+		},
+		{
+			"it should render plantuml diagrams",
+			func(h *Handler, b *bytes.Buffer) error { return h.PlantUML(b) },
+			`@startuml
+package "Strongly Connected Subgraph 0" as scc0 #FFF4D6 {
+  rectangle "nginx" as n1
+  rectangle "ssl" as n3
+}
+rectangle "app" as n0
+rectangle "php (missing)" as n2 #line.dashed
+n0 --> n1
+n0 --> n2
+n1 -[#E01E5A,bold]-> n3
+n3 -[#E01E5A,bold]-> n1
+@enduml
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := qt.New(t)
+
+			h := NewHandler([]string{"cookbooks"}, "roles", WithFS(fsys), WithAllowMissing())
+			c.Assert(h.WalkRole("web", treeprint.New()), qt.IsNil)
+			c.Assert(h.FindSCCs(), qt.IsNil)
+			c.Assert(h.FindCycles(), qt.IsNil)
+
+			var b bytes.Buffer
+			c.Assert(tt.render(h, &b), qt.IsNil)
+			c.Assert(b.String(), qt.Equals, tt.expected)
+		})
+	}
+}
+
+func TestHandlerDiagramsReduce(t *testing.T) {
+	t.Parallel()
+
+	// app's dependency on ssl is implied by its dependency on nginx, as they depend on each other.
+	fsys := fstest.MapFS{
+		"roles/web.json":              {Data: []byte(`{"name": "web", "run_list": ["recipe[app]"]}`)},
+		"cookbooks/app/metadata.rb":   {Data: []byte("name 'app'\ndepends 'nginx'\ndepends 'ssl'\n")},
+		"cookbooks/nginx/metadata.rb": {Data: []byte("name 'nginx'\ndepends 'ssl'\n")},
+		"cookbooks/ssl/metadata.rb":   {Data: []byte("name 'ssl'\ndepends 'nginx'\n")},
+	}
+
+	tests := []struct {
+		name      string
+		render    func(h *Handler, b *bytes.Buffer) error
+		redundant string
+	}{
+		{"it should leave redundant dependencies out of dot graphs", func(h *Handler, b *bytes.Buffer) error { return h.DOT(b) }, `"app" -> "ssl"`},
+		{"it should leave redundant dependencies out of svg images", func(h *Handler, b *bytes.Buffer) error { return h.SVG(b) }, `<title>app -&gt; ssl</title>`},
+		{"it should leave redundant dependencies out of mermaid flowcharts", func(h *Handler, b *bytes.Buffer) error { return h.Mermaid(b) }, "n0 --> n2"},
+		{"it should leave redundant dependencies out of plantuml diagrams", func(h *Handler, b *bytes.Buffer) error { return h.PlantUML(b) }, "n0 --> n2"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := qt.New(t)
+
+			h := NewHandler([]string{"cookbooks"}, "roles", WithFS(fsys))
+			c.Assert(h.WalkRole("web", treeprint.New()), qt.IsNil)
+			c.Assert(h.FindSCCs(), qt.IsNil)
+			c.Assert(h.FindCycles(), qt.IsNil)
+
+			var b bytes.Buffer
+			c.Assert(tt.render(h, &b), qt.IsNil)
+			c.Assert(b.String(), qt.Contains, tt.redundant)
+
+			c.Assert(h.FindRedundantDeps(), qt.IsNil)
+			b.Reset()
+			c.Assert(tt.render(h, &b), qt.IsNil)
+			c.Assert(b.String(), qt.Not(qt.Contains), tt.redundant)
+
+			doc := h.Document()
+			c.Assert(doc.Dependencies, qt.HasLen, 4)
+			c.Assert(doc.Redundant, qt.DeepEquals, []DocumentEdge{{Cookbook: "app", Dependency: "ssl"}})
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package whisk
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// SCC change kinds between two revisions of the dependency graph.
+const (
+	SCCIntroduced = "introduced"
+	SCCResolved   = "resolved"
+	SCCMerged     = "merged"
+	SCCSplit      = "split"
+	SCCGrew       = "grew"
+	SCCShrank     = "shrank"
+	SCCChanged    = "changed"
+)
+
+// SCCChange describes how strongly connected components evolved between two revisions.
+type SCCChange struct {
+	// Kind is the type of change, see the SCC* constants.
+	Kind string `json:"kind"`
+	// Before are the strongly connected components involved, as found in the first revision.
+	Before [][]string `json:"before"`
+	// After are the strongly connected components involved, as found in the second revision.
+	After [][]string `json:"after"`
+}
+
+// GraphDiff holds the differences between the analysis results of two revisions of a role.
+type GraphDiff struct {
+	// AddedCookbooks are the cookbooks only found in the second revision.
+	AddedCookbooks []string `json:"added_cookbooks"`
+	// RemovedCookbooks are the cookbooks only found in the first revision.
+	RemovedCookbooks []string `json:"removed_cookbooks"`
+	// AddedDeps are the {cookbook, dependency} pairs only found in the second revision.
+	AddedDeps [][]string `json:"added_deps"`
+	// RemovedDeps are the {cookbook, dependency} pairs only found in the first revision.
+	RemovedDeps [][]string `json:"removed_deps"`
+	// SCCs are the strongly connected components that changed between revisions.
+	SCCs []SCCChange `json:"sccs"`
+	// IntroducedCycles are the cycles only found in the second revision.
+	IntroducedCycles [][]string `json:"introduced_cycles"`
+	// ResolvedCycles are the cycles only found in the first revision.
+	ResolvedCycles [][]string `json:"resolved_cycles"`
+}
+
+// Diff compares the dependency analysis results of two revisions of the same role.
+func Diff(before, after Result) GraphDiff {
+	var d GraphDiff
+
+	for _, v := range vertices(before.G) {
+		if _, ok := after.G[v]; !ok {
+			d.RemovedCookbooks = append(d.RemovedCookbooks, v)
+		}
+	}
+
+	for _, v := range vertices(after.G) {
+		if _, ok := before.G[v]; !ok {
+			d.AddedCookbooks = append(d.AddedCookbooks, v)
+		}
+	}
+
+	d.RemovedDeps = edgesDiff(before.G, after.G)
+	d.AddedDeps = edgesDiff(after.G, before.G)
+	d.ResolvedCycles = cyclesDiff(before.Cycles, after.Cycles)
+	d.IntroducedCycles = cyclesDiff(after.Cycles, before.Cycles)
+	d.SCCs = sccsDiff(before.Sccs, after.Sccs)
+
+	return d
+}
+
+// Empty reports whether both revisions produced the same dependency graph.
+func (d GraphDiff) Empty() bool {
+	return len(d.AddedCookbooks) == 0 && len(d.RemovedCookbooks) == 0 &&
+		len(d.AddedDeps) == 0 && len(d.RemovedDeps) == 0 && len(d.SCCs) == 0 &&
+		len(d.IntroducedCycles) == 0 && len(d.ResolvedCycles) == 0
+}
+
+// ASCII encodes the differences as a human readable report.
+func (d GraphDiff) ASCII(w io.Writer) {
+	if d.Empty() {
+		fmt.Fprintf(w, "No changes in the dependency graph! 🍻 🎉 \n")
+		return
+	}
+
+	fmt.Fprintf(w, "\n📦 Cookbooks: +%d -%d\n\n", len(d.AddedCookbooks), len(d.RemovedCookbooks))
+	for _, v := range d.AddedCookbooks {
+		fmt.Fprintf(w, "+ %s\n", v)
+	}
+	for _, v := range d.RemovedCookbooks {
+		fmt.Fprintf(w, "- %s\n", v)
+	}
+
+	fmt.Fprintf(w, "\n\n🔗 Dependencies: +%d -%d\n\n", len(d.AddedDeps), len(d.RemovedDeps))
+	for _, e := range d.AddedDeps {
+		fmt.Fprintf(w, "+ %s -> %s\n", e[0], e[1])
+	}
+	for _, e := range d.RemovedDeps {
+		fmt.Fprintf(w, "- %s -> %s\n", e[0], e[1])
+	}
+
+	fmt.Fprintf(w, "\n\n⚠️  Strongly Connected Components changed: %d\n\n", len(d.SCCs))
+	for i, c := range d.SCCs {
+		i++
+		fmt.Fprintf(w, "%d. %s\n", i, c.Kind)
+		for _, scc := range c.Before {
+			fmt.Fprintf(w, "   - %s\n", strings.Join(scc, ", "))
+		}
+		for _, scc := range c.After {
+			fmt.Fprintf(w, "   + %s\n", strings.Join(scc, ", "))
+		}
+	}
+
+	fmt.Fprintf(w, "\n\n🌀 Cycles: +%d -%d\n\n", len(d.IntroducedCycles), len(d.ResolvedCycles))
+	for _, c := range d.IntroducedCycles {
+		fmt.Fprintf(w, "+ %s\n", strings.Join(c, ", "))
+	}
+	for _, c := range d.ResolvedCycles {
+		fmt.Fprintf(w, "- %s\n", strings.Join(c, ", "))
+	}
+}
+
+// JSON encodes the differences to JSON.
+func (d GraphDiff) JSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(d)
+}
+
+// edgesDiff returns the edges in a that are not in b, sorted.
+func edgesDiff(a, b map[string][]string) [][]string {
+	var edges [][]string
+	for _, v := range vertices(a) {
+		found := make(map[string]bool)
+		for _, w := range b[v] {
+			found[w] = true
+		}
+
+		deps := append([]string(nil), a[v]...)
+		sort.Strings(deps)
+		for _, w := range deps {
+			if !found[w] {
+				edges = append(edges, []string{v, w})
+				found[w] = true
+			}
+		}
+	}
+
+	return edges
+}
+
+// cyclesDiff returns the cycles in a that are not in b. Cycles are compared as is, since
+// they always start from their alphabetically smallest cookbook.
+func cyclesDiff(a, b [][]string) [][]string {
+	found := make(map[string]bool)
+	for _, c := range b {
+		found[strings.Join(c, "\x00")] = true
+	}
+
+	var cycles [][]string
+	for _, c := range a {
+		if !found[strings.Join(c, "\x00")] {
+			cycles = append(cycles, c)
+		}
+	}
+
+	return cycles
+}
+
+// sccsDiff matches strongly connected components of two revisions by the cookbooks they
+// share, and classifies how every group of overlapping components changed.
+func sccsDiff(before, after [][]string) []SCCChange {
+	// owner maps every cookbook to the index of the component containing it in the second revision.
+	owner := make(map[string]int)
+	for j, c := range after {
+		for _, v := range c {
+			owner[v] = j
+		}
+	}
+
+	// Groups of overlapping components are found with union-find over a single index space
+	// where components of the second revision are offset by len(before).
+	parent := make([]int, len(before)+len(after))
+	for i := range parent {
+		parent[i] = i
+	}
+
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for i, c := range before {
+		for _, v := range c {
+			if j, ok := owner[v]; ok {
+				parent[find(i)] = find(len(before) + j)
+			}
+		}
+	}
+
+	groups := make(map[int]*SCCChange)
+	var roots []int
+	for i := range parent {
+		r := find(i)
+		if _, ok := groups[r]; !ok {
+			groups[r] = new(SCCChange)
+			roots = append(roots, r)
+		}
+
+		if i < len(before) {
+			groups[r].Before = append(groups[r].Before, before[i])
+		} else {
+			groups[r].After = append(groups[r].After, after[i-len(before)])
+		}
+	}
+
+	var changes []SCCChange
+	for _, r := range roots {
+		g := groups[r]
+		switch {
+		case len(g.Before) == 0:
+			g.Kind = SCCIntroduced
+		case len(g.After) == 0:
+			g.Kind = SCCResolved
+		case len(g.Before) > 1 && len(g.After) == 1:
+			g.Kind = SCCMerged
+		case len(g.Before) == 1 && len(g.After) > 1:
+			g.Kind = SCCSplit
+		case len(g.Before) == 1 && len(g.After) == 1:
+			b, a := toSet(g.Before[0]), toSet(g.After[0])
+			switch {
+			case len(b) == len(a) && contains(a, b):
+				continue // unchanged
+			case contains(a, b):
+				g.Kind = SCCGrew
+			case contains(b, a):
+				g.Kind = SCCShrank
+			default:
+				g.Kind = SCCChanged
+			}
+		default:
+			g.Kind = SCCChanged
+		}
+		changes = append(changes, *g)
+	}
+
+	return changes
+}
+
+// vertices returns the graph's vertices sorted alphabetically.
+func vertices(g map[string][]string) []string {
+	keys := make([]string, 0, len(g))
+	for k := range g {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// toSet returns the set of cookbooks in a strongly connected component.
+func toSet(scc []string) map[string]bool {
+	set := make(map[string]bool, len(scc))
+	for _, v := range scc {
+		set[v] = true
+	}
+
+	return set
+}
+
+// contains reports whether every element of b is in a.
+func contains(a, b map[string]bool) bool {
+	for k := range b {
+		if !a[k] {
+			return false
+		}
+	}
+
+	return true
+}
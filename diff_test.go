@@ -0,0 +1,89 @@
+package whisk
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		before, after Result
+		expected      GraphDiff
+	}{
+		{
+			"it should find no differences between equal results",
+			Result{
+				G:      map[string][]string{"a": {"b"}, "b": {"a"}},
+				Sccs:   [][]string{{"b", "a"}},
+				Cycles: [][]string{{"a", "b", "a"}},
+			},
+			Result{
+				G:      map[string][]string{"a": {"b"}, "b": {"a"}},
+				Sccs:   [][]string{{"b", "a"}},
+				Cycles: [][]string{{"a", "b", "a"}},
+			},
+			GraphDiff{},
+		},
+		{
+			"it should find merged components and introduced cycles",
+			Result{
+				G:      map[string][]string{"a": {"b"}, "b": {"a"}, "c": {"d"}, "d": {"c"}},
+				Sccs:   [][]string{{"b", "a"}, {"d", "c"}},
+				Cycles: [][]string{{"a", "b", "a"}, {"c", "d", "c"}},
+			},
+			Result{
+				G:      map[string][]string{"a": {"b"}, "b": {"a", "c"}, "c": {"d"}, "d": {"c", "a"}},
+				Sccs:   [][]string{{"d", "c", "b", "a"}},
+				Cycles: [][]string{{"a", "b", "a"}, {"a", "b", "c", "d", "a"}, {"c", "d", "c"}},
+			},
+			GraphDiff{
+				AddedDeps: [][]string{{"b", "c"}, {"d", "a"}},
+				SCCs: []SCCChange{
+					{
+						Kind:   SCCMerged,
+						Before: [][]string{{"b", "a"}, {"d", "c"}},
+						After:  [][]string{{"d", "c", "b", "a"}},
+					},
+				},
+				IntroducedCycles: [][]string{{"a", "b", "c", "d", "a"}},
+			},
+		},
+		{
+			"it should find shrunk and resolved components",
+			Result{
+				G:      map[string][]string{"a": {"b"}, "b": {"c"}, "c": {"a"}, "x": {"y"}, "y": {"x"}},
+				Sccs:   [][]string{{"c", "b", "a"}, {"y", "x"}},
+				Cycles: [][]string{{"a", "b", "c", "a"}, {"x", "y", "x"}},
+			},
+			Result{
+				G:      map[string][]string{"a": {"b"}, "b": {"a"}, "c": {}},
+				Sccs:   [][]string{{"b", "a"}},
+				Cycles: [][]string{{"a", "b", "a"}},
+			},
+			GraphDiff{
+				RemovedCookbooks: []string{"x", "y"},
+				AddedDeps:        [][]string{{"b", "a"}},
+				RemovedDeps:      [][]string{{"b", "c"}, {"c", "a"}, {"x", "y"}, {"y", "x"}},
+				SCCs: []SCCChange{
+					{Kind: SCCShrank, Before: [][]string{{"c", "b", "a"}}, After: [][]string{{"b", "a"}}},
+					{Kind: SCCResolved, Before: [][]string{{"y", "x"}}},
+				},
+				ResolvedCycles:   [][]string{{"a", "b", "c", "a"}, {"x", "y", "x"}},
+				IntroducedCycles: [][]string{{"a", "b", "a"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := qt.New(t)
+			c.Assert(Diff(tt.before, tt.after), qt.DeepEquals, tt.expected)
+		})
+	}
+}
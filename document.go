@@ -0,0 +1,256 @@
+package whisk
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SchemaVersion is the version of the JSON document's schema. It's bumped whenever a field is
+// removed, renamed or changes meaning, while new fields can be added within the same version.
+const SchemaVersion = 1
+
+// Schema is the JSON Schema documents are described by.
+//
+//go:embed schema/whisk.schema.json
+var Schema []byte
+
+// Document is the versioned JSON representation of the dependency analysis, as described by Schema.
+type Document struct {
+	// SchemaVersion is the version of the schema the document follows.
+	SchemaVersion int `json:"schema_version"`
+	// Node is the node whose run list was walked, when analyzing a node.
+	Node *DocumentNode `json:"node,omitempty"`
+	// RunList holds the run list entries walked.
+	RunList []string `json:"run_list"`
+	// Roles are the roles walked, in the order they were first found.
+	Roles []DocumentRole `json:"roles"`
+	// Cookbooks are the dependency graph's vertices, sorted by name.
+	Cookbooks []DocumentCookbook `json:"cookbooks"`
+	// Dependencies are the dependency graph's edges, sorted by cookbook and dependency.
+	Dependencies []DocumentDependency `json:"dependencies"`
+	// SCCs are the strongly connected components found, topologically sorted.
+	SCCs []DocumentSCC `json:"sccs"`
+	// Cycles are the distinct cycles found.
+	Cycles []DocumentCycle `json:"cycles"`
+	// CookbookCycles ranks every cookbook by the number of cycles going through it.
+	CookbookCycles []CookbookParticipation `json:"cookbook_cycles"`
+	// EdgeCycles ranks every dependency by the number of cycles going through it.
+	EdgeCycles []EdgeParticipation `json:"edge_cycles"`
+	// Redundant are the dependencies implied by other dependencies, when they were looked for.
+	Redundant []DocumentEdge `json:"redundant,omitempty"`
+	// Warnings are the problems found that don't prevent the analysis, such as role validation
+	// violations or missing cookbooks.
+	Warnings []Warning `json:"warnings"`
+	// Discrepancies are the differences found cross-checking a Berksfile.lock with cookbooks metadata.
+	Discrepancies []Discrepancy `json:"discrepancies,omitempty"`
+}
+
+// DocumentNode is a node whose run list was walked.
+type DocumentNode struct {
+	// Name is the node's name.
+	Name string `json:"name"`
+	// Path is the path of the node's export.
+	Path string `json:"path"`
+}
+
+// DocumentRole is a role walked.
+type DocumentRole struct {
+	// Name is the role's name.
+	Name string `json:"name"`
+	// Description describes what the role is for.
+	Description string `json:"description,omitempty"`
+	// Path is the path of the role's file.
+	Path string `json:"path"`
+	// RunList is the role's run list, as declared.
+	RunList []string `json:"run_list"`
+}
+
+// DocumentCookbook is a vertex of the dependency graph.
+type DocumentCookbook struct {
+	// Name is the cookbook's name.
+	Name string `json:"name"`
+	// Path is the directory the cookbook was found in, empty when missing.
+	Path string `json:"path,omitempty"`
+	// Version is the cookbook's version, as declared in its metadata.
+	Version string `json:"version,omitempty"`
+	// Missing is whether the cookbook wasn't found in any cookbook path.
+	Missing bool `json:"missing"`
+	// SCC is the id of the strongly connected component the cookbook belongs to, if any.
+	SCC *int `json:"scc"`
+	// Cycles is the number of cycles going through the cookbook.
+	Cycles int `json:"cycles"`
+}
+
+// DocumentDependency is an edge of the dependency graph.
+type DocumentDependency struct {
+	// Cookbook is the name of the cookbook declaring the dependency.
+	Cookbook string `json:"cookbook"`
+	// Dependency is the name of the cookbook depended upon.
+	Dependency string `json:"dependency"`
+	// Constraint is the dependency's version constraint, if any.
+	Constraint string `json:"constraint,omitempty"`
+	// File is the path of the metadata file declaring the dependency.
+	File string `json:"file,omitempty"`
+	// Line is the line of the metadata file declaring the dependency, starting at 1.
+	Line int `json:"line,omitempty"`
+	// Cycles is the number of cycles going through the dependency.
+	Cycles int `json:"cycles"`
+}
+
+// DocumentSCC is a strongly connected component.
+type DocumentSCC struct {
+	// ID identifies the component, and is its index in the topologically sorted components.
+	ID int `json:"id"`
+	// Cookbooks are the component's cookbooks, sorted by name.
+	Cookbooks []string `json:"cookbooks"`
+}
+
+// DocumentCycle is a distinct cycle.
+type DocumentCycle struct {
+	// SCC is the id of the strongly connected component the cycle goes through, if any. Cookbooks
+	// depending on themselves don't form strongly connected components.
+	SCC *int `json:"scc"`
+	// Cookbooks are the cookbooks the cycle goes through, starting and ending with the same one.
+	Cookbooks []string `json:"cookbooks"`
+}
+
+// DocumentEdge is an edge of the dependency graph, identified by the cookbooks it connects.
+type DocumentEdge struct {
+	// Cookbook is the name of the cookbook declaring the dependency.
+	Cookbook string `json:"cookbook"`
+	// Dependency is the name of the cookbook depended upon.
+	Dependency string `json:"dependency"`
+}
+
+// Warning is a problem found that doesn't prevent the analysis.
+type Warning struct {
+	// Rule identifies the kind of problem, such as a role validation rule.
+	Rule string `json:"rule"`
+	// Message describes the problem.
+	Message string `json:"message"`
+	// Role is the name of the role at fault, if any.
+	Role string `json:"role,omitempty"`
+	// Path is the path of the file at fault, if any.
+	Path string `json:"path,omitempty"`
+}
+
+// Document returns the dependency analysis results as a versioned JSON document.
+func (h *Handler) Document() Document {
+	d := h.newDiagram()
+
+	doc := Document{
+		SchemaVersion:  SchemaVersion,
+		RunList:        append([]string{}, h.runList...),
+		Roles:          make([]DocumentRole, 0, len(h.walkedRoles)),
+		Cookbooks:      make([]DocumentCookbook, 0, len(d.vertices)),
+		Dependencies:   make([]DocumentDependency, 0, len(d.edges)),
+		SCCs:           make([]DocumentSCC, 0, len(h.sccs)),
+		Cycles:         make([]DocumentCycle, 0, len(h.cycles)),
+		CookbookCycles: h.cookbookParticipation(),
+		EdgeCycles:     h.edgeParticipation(),
+		Warnings:       make([]Warning, 0, len(h.violations)+len(h.missing)),
+	}
+
+	sccID := func(v string) *int {
+		if i, ok := d.sccs[v]; ok {
+			return &i
+		}
+
+		return nil
+	}
+
+	for _, name := range h.walkedRoles {
+		if role, ok := h.rolesIndex[name]; ok {
+			doc.Roles = append(doc.Roles, DocumentRole{
+				Name:        role.Name,
+				Description: role.Description,
+				Path:        role.Path,
+				RunList:     append([]string{}, role.RunList...),
+			})
+		}
+	}
+
+	for _, v := range d.vertices {
+		c := DocumentCookbook{
+			Name:    v,
+			Missing: h.missing[v],
+			SCC:     sccID(v),
+			Cycles:  h.participation.Vertices[v],
+		}
+		if cookbook, ok := h.cookbooks[v]; ok {
+			c.Path = cookbook.Path
+			c.Version = cookbook.Version
+		}
+		doc.Cookbooks = append(doc.Cookbooks, c)
+	}
+
+	for _, e := range d.edges {
+		dep := DocumentDependency{
+			Cookbook:   e[0],
+			Dependency: e[1],
+			Cycles:     h.participation.Edges[e[0]][e[1]],
+		}
+		if cookbook, ok := h.cookbooks[e[0]]; ok {
+			dep.Constraint = cookbook.Deps[e[1]]
+		}
+		dep.File, dep.Line = h.DependencyLocation(e[0], e[1])
+		doc.Dependencies = append(doc.Dependencies, dep)
+	}
+
+	for i, scc := range h.sccs {
+		doc.SCCs = append(doc.SCCs, DocumentSCC{ID: i, Cookbooks: sortedSCCVertices(scc)})
+	}
+
+	for _, c := range h.cycles {
+		doc.Cycles = append(doc.Cycles, DocumentCycle{SCC: sccID(c[0]), Cookbooks: c})
+	}
+
+	for _, e := range h.redundant {
+		doc.Redundant = append(doc.Redundant, DocumentEdge{Cookbook: e[0], Dependency: e[1]})
+	}
+
+	for _, v := range h.reportedViolations() {
+		doc.Warnings = append(doc.Warnings, Warning{Rule: v.Rule, Message: v.Message, Role: v.Role, Path: v.Path})
+	}
+
+	// missing cookbooks are reported once, along with the roles and cookbooks requiring them, and
+	// located at the first role referencing them, if any.
+	for _, m := range h.missingCookbooks() {
+		w := Warning{Rule: RuleMissingCookbook}
+
+		var requiredBy []string
+		for _, v := range h.violations {
+			if v.Rule == RuleMissingCookbook && v.Cookbook == m.Cookbook {
+				if w.Role == "" {
+					w.Role, w.Path = v.Role, v.Path
+				}
+				requiredBy = append(requiredBy, "role "+v.Role)
+			}
+		}
+		requiredBy = append(requiredBy, m.RequiredBy...)
+
+		if len(requiredBy) == 0 {
+			requiredBy = []string{"the run list"}
+		}
+		w.Message = fmt.Sprintf("cookbook %q wasn't found in any cookbook path, required by %s", m.Cookbook, strings.Join(requiredBy, ", "))
+		doc.Warnings = append(doc.Warnings, w)
+	}
+
+	return doc
+}
+
+// Encode writes the document as JSON.
+func (d Document) Encode(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	return enc.Encode(d)
+}
+
+// JSON encodes the dependency analysis results as a versioned JSON document, as described by Schema.
+func (h *Handler) JSON(w io.Writer) error {
+	return h.Document().Encode(w)
+}
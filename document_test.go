@@ -0,0 +1,256 @@
+package whisk
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/xlab/treeprint"
+)
+
+// update rewrites golden files with the current output instead of comparing against them.
+var update = flag.Bool("update", false, "update golden files")
+
+// documentHandler returns a handler having analyzed a role exercising every field of the document.
+func documentHandler(c *qt.C) *Handler {
+	fsys := fstest.MapFS{
+		"roles/web.json":              {Data: []byte(`{"name": "web", "description": "Serves the web", "json_class": "Chef::Role", "chef_type": "role", "run_list": ["role[base]", "recipe[app]", "recipe[vault]"]}`)},
+		"roles/base.json":             {Data: []byte(`{"name": "base", "run_list": ["recipe[ntp]"]}`)},
+		"cookbooks/app/metadata.rb":   {Data: []byte("name 'app'\nversion '1.0.0'\ndepends 'nginx', '~> 2.0'\ndepends 'ssl'\ndepends 'vault'\n")},
+		"cookbooks/ntp/metadata.rb":   {Data: []byte("name 'ntp'\nversion '3.2.1'\n")},
+		"cookbooks/nginx/metadata.rb": {Data: []byte("name 'nginx'\nversion '2.1.0'\ndepends 'ssl'\n")},
+		"cookbooks/ssl/metadata.json": {Data: []byte("{\n  \"name\": \"ssl\",\n  \"version\": \"3.0.0\",\n  \"dependencies\": {\n    \"nginx\": \">= 2.0\"\n  }\n}\n")},
+	}
+
+	h := NewHandler([]string{"cookbooks"}, "roles", WithFS(fsys), WithAllowMissing())
+	c.Assert(h.WalkRole("web", treeprint.New()), qt.IsNil)
+	c.Assert(h.ValidateRoles(), qt.IsNil)
+	c.Assert(h.FindSCCs(), qt.IsNil)
+	c.Assert(h.FindCycles(), qt.IsNil)
+	c.Assert(h.FindRedundantDeps(), qt.IsNil)
+
+	return h
+}
+
+func TestHandlerJSONGolden(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+
+	var b bytes.Buffer
+	c.Assert(documentHandler(c).JSON(&b), qt.IsNil)
+
+	var got bytes.Buffer
+	c.Assert(json.Indent(&got, b.Bytes(), "", "  "), qt.IsNil)
+	got.WriteString("\n")
+
+	golden := filepath.Join("testdata", "document.golden.json")
+	if *update {
+		c.Assert(os.WriteFile(golden, got.Bytes(), 0o644), qt.IsNil)
+	}
+
+	want, err := os.ReadFile(golden)
+	c.Assert(err, qt.IsNil)
+	c.Assert(got.String(), qt.Equals, string(want))
+}
+
+func TestDocumentMissingCookbooks(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+
+	var warnings []Warning
+	for _, w := range documentHandler(c).Document().Warnings {
+		if w.Rule == RuleMissingCookbook {
+			warnings = append(warnings, w)
+		}
+	}
+
+	c.Assert(warnings, qt.DeepEquals, []Warning{{
+		Rule:    RuleMissingCookbook,
+		Message: `cookbook "vault" wasn't found in any cookbook path, required by role web, app`,
+		Role:    "web",
+		Path:    "roles/web.json",
+	}})
+}
+
+func TestSchema(t *testing.T) {
+	t.Parallel()
+
+	var schema map[string]any
+	qt.Assert(t, json.Unmarshal(Schema, &schema), qt.IsNil)
+
+	t.Run("it should match the schema version", func(t *testing.T) {
+		t.Parallel()
+		c := qt.New(t)
+
+		version := schema["properties"].(map[string]any)["schema_version"].(map[string]any)["const"]
+		c.Assert(version, qt.Equals, float64(SchemaVersion))
+	})
+
+	t.Run("it should describe the document", func(t *testing.T) {
+		t.Parallel()
+		c := qt.New(t)
+
+		var b bytes.Buffer
+		c.Assert(documentHandler(c).JSON(&b), qt.IsNil)
+
+		var doc any
+		c.Assert(json.Unmarshal(b.Bytes(), &doc), qt.IsNil)
+		c.Assert(validate(schema, schema, doc, "$"), qt.HasLen, 0)
+	})
+
+	t.Run("it should describe empty documents", func(t *testing.T) {
+		t.Parallel()
+		c := qt.New(t)
+
+		var b bytes.Buffer
+		c.Assert(NewHandler(nil, "roles").JSON(&b), qt.IsNil)
+
+		var doc any
+		c.Assert(json.Unmarshal(b.Bytes(), &doc), qt.IsNil)
+		c.Assert(validate(schema, schema, doc, "$"), qt.HasLen, 0)
+	})
+
+	t.Run("it should describe nodes and Berksfile.lock discrepancies", func(t *testing.T) {
+		t.Parallel()
+		c := qt.New(t)
+
+		d := documentHandler(c).Document()
+		d.Node = &DocumentNode{Name: "web-1", Path: "nodes/web-1.json"}
+		d.Discrepancies = []Discrepancy{
+			{Cookbook: "app", Dependency: "vault", Reason: OnlyInMetadata},
+			{Cookbook: "ssl", Reason: MetadataNotFound},
+		}
+
+		var b bytes.Buffer
+		c.Assert(d.Encode(&b), qt.IsNil)
+
+		var doc any
+		c.Assert(json.Unmarshal(b.Bytes(), &doc), qt.IsNil)
+		c.Assert(validate(schema, schema, doc, "$"), qt.HasLen, 0)
+
+		doc.(map[string]any)["discrepancies"].([]any)[0].(map[string]any)["reason"] = "unknown"
+		c.Assert(validate(schema, schema, doc, "$"), qt.DeepEquals, []string{
+			"$.discrepancies[0].reason: expected one of [only in metadata only in Berksfile.lock metadata not found], found unknown",
+		})
+	})
+
+	t.Run("it should reject documents not following it", func(t *testing.T) {
+		t.Parallel()
+		c := qt.New(t)
+
+		var doc any
+		c.Assert(json.Unmarshal([]byte(`{"schema_version": 2, "run_list": [], "roles": [], "cookbooks": [{"name": "a", "missing": false, "scc": -1, "cycles": 0, "extra": true}], "dependencies": [], "sccs": [], "cycles": [], "cookbook_cycles": [], "edge_cycles": []}`), &doc), qt.IsNil)
+		c.Assert(validate(schema, schema, doc, "$"), qt.DeepEquals, []string{
+			"$: missing required property warnings",
+			"$.cookbooks[0]: unexpected property extra",
+			"$.cookbooks[0].scc: -1 is less than 0",
+			"$.schema_version: expected 1, found 2",
+		})
+	})
+}
+
+// validate returns the errors found validating value against the JSON Schema, supporting the
+// keywords Schema uses: $ref, const, enum, type, minimum, required, properties, additionalProperties and items.
+func validate(root, schema map[string]any, value any, path string) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		def := strings.TrimPrefix(ref, "#/$defs/")
+		return validate(root, root["$defs"].(map[string]any)[def].(map[string]any), value, path)
+	}
+
+	var errs []string
+	if want, ok := schema["const"]; ok && want != value {
+		errs = append(errs, fmt.Sprintf("%s: expected %v, found %v", path, want, value))
+	}
+
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, want := range enum {
+			found = found || want == value
+		}
+		if !found {
+			errs = append(errs, fmt.Sprintf("%s: expected one of %v, found %v", path, enum, value))
+		}
+	}
+
+	if t, ok := schema["type"]; ok {
+		types := []any{t}
+		if list, ok := t.([]any); ok {
+			types = list
+		}
+
+		matched := false
+		for _, t := range types {
+			matched = matched || hasType(value, t.(string))
+		}
+		if !matched {
+			return append(errs, fmt.Sprintf("%s: expected %v, found %T", path, t, value))
+		}
+	}
+
+	switch v := value.(type) {
+	case float64:
+		if min, ok := schema["minimum"].(float64); ok && v < min {
+			errs = append(errs, fmt.Sprintf("%s: %v is less than %v", path, v, min))
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				errs = append(errs, validate(root, items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case map[string]any:
+		for _, r := range schema["required"].([]any) {
+			if _, ok := v[r.(string)]; !ok {
+				errs = append(errs, fmt.Sprintf("%s: missing required property %s", path, r))
+			}
+		}
+
+		properties := schema["properties"].(map[string]any)
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			p, ok := properties[k]
+			if !ok {
+				if schema["additionalProperties"] == false {
+					errs = append(errs, fmt.Sprintf("%s: unexpected property %s", path, k))
+				}
+				continue
+			}
+			errs = append(errs, validate(root, p.(map[string]any), v[k], path+"."+k)...)
+		}
+	}
+
+	return errs
+}
+
+// hasType returns whether the decoded JSON value is of the given JSON Schema type.
+func hasType(value any, t string) bool {
+	switch v := value.(type) {
+	case nil:
+		return t == "null"
+	case bool:
+		return t == "boolean"
+	case string:
+		return t == "string"
+	case float64:
+		return t == "number" || (t == "integer" && v == float64(int64(v)))
+	case []any:
+		return t == "array"
+	case map[string]any:
+		return t == "object"
+	}
+
+	return false
+}
@@ -0,0 +1,291 @@
+package whisk
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+
+	"slack/whisk/chef"
+)
+
+// graphviz dot template
+const dotTpl = `
+{{- define "vertex" }}{{ quote .ID }}{{ with .Attrs }} [{{ join . ", " }}]{{ end }};{{ end -}}
+digraph g {
+	bgcolor = "#ffffff"
+	splines = ortho
+	overlap = true
+	newrank = true
+
+	node [
+		shape = rectangle,
+		width = 0.25,
+		color = "#323538",
+		fillcolor = white,
+		style = "filled, solid",
+		fontcolor = "#323538",
+		fontsize = 8,
+	]
+
+	edge [
+		penwidth = 0.50,
+		color = "#323538",
+		arrowhead = "vee"
+	]
+{{- range .Clusters }}
+
+	subgraph {{ quote .ID }} {
+		style = "{{ .Style }}";
+		color = "{{ .Color }}";
+		label = {{ quote .Label }};
+{{ range .Vertices }}
+		{{ template "vertex" . }}
+{{- end }}
+	}
+{{- end }}
+{{ range .Vertices }}
+	{{ template "vertex" . }}
+{{- end }}
+{{ range .Edges }}
+	{{ quote .From }} -> {{ quote .To }}{{ with .Attrs }} [{{ join . ", " }}]{{ end }};
+{{- end }}
+}
+`
+
+// dotQuote escapes backslashes and quotes, which DOT doesn't allow unescaped in quoted identifiers.
+var dotQuote = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// quote returns s as a quoted DOT identifier, so cookbook names with dots, leading digits or any
+// other character are valid DOT.
+func quote(s string) string {
+	return `"` + dotQuote.Replace(s) + `"`
+}
+
+// dotVertex is a vertex as rendered in DOT, along with its attributes.
+type dotVertex struct {
+	ID    string
+	Attrs []string
+}
+
+// dotEdge is an edge as rendered in DOT, along with its attributes.
+type dotEdge struct {
+	From  string
+	To    string
+	Attrs []string
+}
+
+// dotCluster is a group of vertices rendered as a DOT cluster.
+type dotCluster struct {
+	ID       string
+	Label    string
+	Style    string
+	Color    string
+	Vertices []dotVertex
+}
+
+// dotGraph holds the values dotTpl is executed with.
+type dotGraph struct {
+	Clusters []dotCluster
+	Vertices []dotVertex
+	Edges    []dotEdge
+}
+
+// dotOptions holds the settings DOT renders the dependency graph with.
+type dotOptions struct {
+	// focus is the index of the only strongly connected component rendered, or -1 to render them all.
+	focus int
+	// clusterByPath groups cookbooks by the cookbook path they were found in, instead of by strongly
+	// connected component.
+	clusterByPath bool
+}
+
+// DOTOption configures how DOT renders the dependency graph.
+type DOTOption func(*dotOptions)
+
+// FocusSCC renders only the cookbooks of the i-th strongly connected component, as numbered in
+// the clusters' labels, and the dependencies between them.
+func FocusSCC(i int) DOTOption {
+	return func(o *dotOptions) {
+		o.focus = i
+	}
+}
+
+// ClusterByPath groups cookbooks by the cookbook path they were found in, such as cookbooks or
+// site-cookbooks, instead of by strongly connected component. Cookbooks in strongly connected
+// components are still colored.
+func ClusterByPath() DOTOption {
+	return func(o *dotOptions) {
+		o.clusterByPath = true
+	}
+}
+
+// roleVertex returns the vertex of a role, named as in run lists so it doesn't clash with cookbooks.
+func roleVertex(name string) string {
+	return fmt.Sprintf("role[%s]", name)
+}
+
+// DOT encodes the dependency graph to graphviz's dot format. Roles walked are drawn along with the
+// cookbooks in their run lists, strongly connected components are clustered, and dependencies in
+// cycles are drawn in red.
+func (h *Handler) DOT(w io.Writer, opts ...DOTOption) error {
+	o := dotOptions{focus: -1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.focus >= len(h.sccs) || o.focus < -1 {
+		return fmt.Errorf("strongly connected component %d doesn't exist, found %d", o.focus, len(h.sccs))
+	}
+
+	funcMap := template.FuncMap{
+		"quote": quote,
+		"join":  strings.Join,
+	}
+
+	tpl, err := template.New("graph").Funcs(funcMap).Parse(dotTpl)
+	if err != nil {
+		return fmt.Errorf("failed parsing dot template: %w", err)
+	}
+
+	if err := tpl.Execute(w, h.dotGraph(o)); err != nil {
+		return fmt.Errorf("failed executing dot template: %w", err)
+	}
+
+	return nil
+}
+
+// dotGraph lays out the dependency graph for dotTpl.
+func (h *Handler) dotGraph(o dotOptions) dotGraph {
+	d := h.newDiagram()
+	d.reduce(h.redundant)
+
+	included := func(v string) bool {
+		if o.focus < 0 {
+			return true
+		}
+		i, ok := d.sccs[v]
+
+		return ok && i == o.focus
+	}
+
+	vertex := func(v string) dotVertex {
+		attrs := []string{"label = " + quote(h.label(v))}
+		if _, ok := d.sccs[v]; ok {
+			attrs = append(attrs, `color = "#F2C744"`)
+		}
+		if h.missing[v] {
+			attrs = append(attrs, `style = "dashed"`, `fontcolor = "#9A9A9A"`)
+		}
+
+		return dotVertex{ID: v, Attrs: attrs}
+	}
+
+	var g dotGraph
+	clustered := make(map[string]bool)
+	if o.clusterByPath {
+		var paths []string
+		dirs := make(map[string][]dotVertex)
+		for _, v := range d.vertices {
+			if c, ok := h.cookbooks[v]; ok && included(v) {
+				dir := path.Dir(c.Path)
+				if _, ok := dirs[dir]; !ok {
+					paths = append(paths, dir)
+				}
+				dirs[dir] = append(dirs[dir], vertex(v))
+				clustered[v] = true
+			}
+		}
+		sort.Strings(paths)
+
+		for i, dir := range paths {
+			g.Clusters = append(g.Clusters, dotCluster{
+				ID:       fmt.Sprintf("cluster_path%d", i),
+				Label:    dir,
+				Style:    "rounded, dashed",
+				Color:    "#9A9A9A",
+				Vertices: dirs[dir],
+			})
+		}
+	} else {
+		for i, scc := range h.sccs {
+			if o.focus >= 0 && i != o.focus {
+				continue
+			}
+
+			cluster := dotCluster{
+				ID:    fmt.Sprintf("cluster_sccs%d", i),
+				Label: sccLabel(i),
+				Style: "filled, solid",
+				Color: "#F2C744",
+			}
+			for _, v := range sortedSCCVertices(scc) {
+				cluster.Vertices = append(cluster.Vertices, vertex(v))
+				clustered[v] = true
+			}
+			g.Clusters = append(g.Clusters, cluster)
+		}
+	}
+
+	for _, v := range d.vertices {
+		if !clustered[v] && included(v) {
+			g.Vertices = append(g.Vertices, vertex(v))
+		}
+	}
+
+	for _, e := range d.edges {
+		if !included(e[0]) || !included(e[1]) {
+			continue
+		}
+
+		edge := dotEdge{From: e[0], To: e[1]}
+		if h.inCycle(e) {
+			edge.Attrs = []string{fmt.Sprintf("color = %q", cycleColor), "penwidth = 1.5"}
+		}
+		g.Edges = append(g.Edges, edge)
+	}
+
+	if o.focus < 0 {
+		h.addDOTRoles(&g)
+	}
+
+	return g
+}
+
+// addDOTRoles adds the roles walked to the graph, drawn as rounded boxes depending on the roles and
+// cookbooks in their run lists through dashed edges.
+func (h *Handler) addDOTRoles(g *dotGraph) {
+	for _, name := range h.walkedRoles {
+		role, ok := h.rolesIndex[name]
+		if !ok {
+			continue
+		}
+
+		g.Vertices = append(g.Vertices, dotVertex{
+			ID:    roleVertex(name),
+			Attrs: []string{"label = " + quote(name), `style = "rounded, filled"`, `color = "#36C5F0"`, `fillcolor = "#E8F5FA"`},
+		})
+
+		seen := make(map[string]bool)
+		for _, entry := range role.RunList {
+			item, err := chef.ParseRunListItem(entry)
+			if err != nil {
+				continue
+			}
+
+			to := item.Name
+			if item.Type == chef.RoleItem {
+				to = roleVertex(item.Name)
+			}
+
+			if seen[to] {
+				continue
+			}
+			seen[to] = true
+
+			g.Edges = append(g.Edges, dotEdge{From: roleVertex(name), To: to, Attrs: []string{`style = "dashed"`}})
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package whisk
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/xlab/treeprint"
+)
+
+func TestHandlerDOT(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"roles/web.json":                        {Data: []byte(`{"name": "web", "run_list": ["role[base]", "recipe[app]"]}`)},
+		"roles/base.json":                       {Data: []byte(`{"name": "base", "run_list": ["recipe[7zip]"]}`)},
+		"cookbooks/app/metadata.rb":             {Data: []byte("name 'app'\ndepends 'nginx.conf'\n")},
+		"cookbooks/7zip/metadata.rb":            {Data: []byte("name '7zip'\n")},
+		"site-cookbooks/nginx.conf/metadata.rb": {Data: []byte("name 'nginx.conf'\ndepends 'ssl'\n")},
+		"site-cookbooks/ssl/metadata.rb":        {Data: []byte("name 'ssl'\ndepends 'nginx.conf'\ndepends 'vault'\n")},
+	}
+
+	h := NewHandler([]string{"cookbooks", "site-cookbooks"}, "roles", WithFS(fsys), WithAllowMissing())
+	qt.Assert(t, h.WalkRole("web", treeprint.New()), qt.IsNil)
+	qt.Assert(t, h.FindSCCs(), qt.IsNil)
+	qt.Assert(t, h.FindCycles(), qt.IsNil)
+
+	tests := []struct {
+		name     string
+		opts     []DOTOption
+		contains []string
+		excludes []string
+	}{
+		{
+			"it should quote identifiers and keep original names as labels",
+			nil,
+			[]string{
+				`"7zip" [label = "7zip"];`,
+				`"nginx.conf" [label = "nginx.conf", color = "#F2C744"];`,
+				`"vault" [label = "vault (missing)", style = "dashed", fontcolor = "#9A9A9A"];`,
+				`"app" -> "nginx.conf";`,
+			},
+			nil,
+		},
+		{
+			"it should draw dependencies in cycles in red",
+			nil,
+			[]string{
+				`"nginx.conf" -> "ssl" [color = "#E01E5A", penwidth = 1.5];`,
+				`"ssl" -> "nginx.conf" [color = "#E01E5A", penwidth = 1.5];`,
+				`"ssl" -> "vault";`,
+			},
+			nil,
+		},
+		{
+			"it should draw roles and their run lists",
+			nil,
+			[]string{
+				`"role[web]" [label = "web", style = "rounded, filled", color = "#36C5F0", fillcolor = "#E8F5FA"];`,
+				`"role[web]" -> "role[base]" [style = "dashed"];`,
+				`"role[web]" -> "app" [style = "dashed"];`,
+				`"role[base]" -> "7zip" [style = "dashed"];`,
+			},
+			nil,
+		},
+		{
+			"it should cluster strongly connected components",
+			nil,
+			[]string{
+				`subgraph "cluster_sccs0" {`,
+				`label = "Strongly Connected Subgraph 0";`,
+			},
+			[]string{`subgraph "cluster_path0" {`},
+		},
+		{
+			"it should only render the strongly connected component focused",
+			[]DOTOption{FocusSCC(0)},
+			[]string{
+				`subgraph "cluster_sccs0" {`,
+				`"nginx.conf" -> "ssl" [color = "#E01E5A", penwidth = 1.5];`,
+			},
+			[]string{`"app"`, `"vault"`, `"role[web]"`},
+		},
+		{
+			"it should cluster cookbooks by path",
+			[]DOTOption{ClusterByPath()},
+			[]string{
+				`label = "cookbooks";`,
+				`label = "site-cookbooks";`,
+			},
+			[]string{`subgraph "cluster_sccs0" {`},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := qt.New(t)
+
+			var b bytes.Buffer
+			c.Assert(h.DOT(&b, tt.opts...), qt.IsNil)
+
+			for _, s := range tt.contains {
+				c.Assert(b.String(), qt.Contains, s)
+			}
+			for _, s := range tt.excludes {
+				c.Assert(b.String(), qt.Not(qt.Contains), s)
+			}
+		})
+	}
+
+	t.Run("it should fail focusing on strongly connected components not found", func(t *testing.T) {
+		t.Parallel()
+		c := qt.New(t)
+
+		var b bytes.Buffer
+		c.Assert(h.DOT(&b, FocusSCC(1)), qt.ErrorMatches, "strongly connected component 1 doesn't exist, found 1")
+	})
+}
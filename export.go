@@ -0,0 +1,297 @@
+package whisk
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// exportNode is a cookbook along with the attributes exported to graph tools.
+type exportNode struct {
+	name      string
+	scc       int
+	path      string
+	version   string
+	inCycle   bool
+	missing   bool
+	inDegree  int
+	outDegree int
+}
+
+// exportEdge is a dependency along with the attributes exported to graph tools.
+type exportEdge struct {
+	source     string
+	target     string
+	constraint string
+	cycles     int
+}
+
+// exportGraph returns the dependency graph's nodes, sorted alphabetically, and edges, by source then
+// target, along with their attributes. Cookbooks outside strongly connected components get -1 as SCC id.
+func (h *Handler) exportGraph() ([]exportNode, []exportEdge) {
+	d := h.newDiagram()
+
+	inDegree := make(map[string]int, len(d.vertices))
+	for _, e := range d.edges {
+		inDegree[e[1]]++
+	}
+
+	nodes := make([]exportNode, 0, len(d.vertices))
+	for _, v := range d.vertices {
+		n := exportNode{
+			name:      v,
+			scc:       -1,
+			inCycle:   h.participation.Vertices[v] > 0,
+			missing:   h.missing[v],
+			inDegree:  inDegree[v],
+			outDegree: len(h.graph[v]),
+		}
+
+		if i, ok := d.sccs[v]; ok {
+			n.scc = i
+		}
+
+		if c, ok := h.cookbooks[v]; ok {
+			n.path = c.Path
+			n.version = c.Version
+		}
+
+		nodes = append(nodes, n)
+	}
+
+	edges := make([]exportEdge, 0, len(d.edges))
+	for _, e := range d.edges {
+		edge := exportEdge{source: e[0], target: e[1], cycles: h.participation.Edges[e[0]][e[1]]}
+		if c, ok := h.cookbooks[e[0]]; ok {
+			edge.constraint = c.Deps[e[1]]
+		}
+
+		edges = append(edges, edge)
+	}
+
+	return nodes, edges
+}
+
+// graphMLKey declares a GraphML attribute.
+type graphMLKey struct {
+	ID      string `xml:"id,attr"`
+	For     string `xml:"for,attr"`
+	Name    string `xml:"attr.name,attr"`
+	Type    string `xml:"attr.type,attr"`
+	Default string `xml:"default,omitempty"`
+}
+
+// graphMLData is the value of a GraphML attribute.
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// graphMLNode is a GraphML node.
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+// graphMLEdge is a GraphML edge.
+type graphMLEdge struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+// graphML is a GraphML document with a single directed graph.
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   struct {
+		ID          string        `xml:"id,attr"`
+		EdgeDefault string        `xml:"edgedefault,attr"`
+		Nodes       []graphMLNode `xml:"node"`
+		Edges       []graphMLEdge `xml:"edge"`
+	} `xml:"graph"`
+}
+
+// GraphML encodes the dependency graph as GraphML, for graph tools such as yEd or Cytoscape. Cookbooks
+// carry their SCC id, path, version, in-cycle flag and degrees, and dependencies their version
+// constraint and the number of cycles going through them.
+func (h *Handler) GraphML(w io.Writer) error {
+	nodes, edges := h.exportGraph()
+
+	doc := graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "label", For: "node", Name: "label", Type: "string"},
+			{ID: "scc", For: "node", Name: "scc", Type: "int", Default: "-1"},
+			{ID: "path", For: "node", Name: "path", Type: "string"},
+			{ID: "version", For: "node", Name: "version", Type: "string"},
+			{ID: "in_cycle", For: "node", Name: "in_cycle", Type: "boolean", Default: "false"},
+			{ID: "missing", For: "node", Name: "missing", Type: "boolean", Default: "false"},
+			{ID: "in_degree", For: "node", Name: "in_degree", Type: "int"},
+			{ID: "out_degree", For: "node", Name: "out_degree", Type: "int"},
+			{ID: "constraint", For: "edge", Name: "constraint", Type: "string"},
+			{ID: "cycles", For: "edge", Name: "cycles", Type: "int", Default: "0"},
+		},
+	}
+	doc.Graph.ID = "G"
+	doc.Graph.EdgeDefault = "directed"
+
+	for _, n := range nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{
+			ID: n.name,
+			Data: []graphMLData{
+				{Key: "label", Value: n.name},
+				{Key: "scc", Value: strconv.Itoa(n.scc)},
+				{Key: "path", Value: n.path},
+				{Key: "version", Value: n.version},
+				{Key: "in_cycle", Value: strconv.FormatBool(n.inCycle)},
+				{Key: "missing", Value: strconv.FormatBool(n.missing)},
+				{Key: "in_degree", Value: strconv.Itoa(n.inDegree)},
+				{Key: "out_degree", Value: strconv.Itoa(n.outDegree)},
+			},
+		})
+	}
+
+	for i, e := range edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+			ID:     fmt.Sprintf("e%d", i),
+			Source: e.source,
+			Target: e.target,
+			Data: []graphMLData{
+				{Key: "constraint", Value: e.constraint},
+				{Key: "cycles", Value: strconv.Itoa(e.cycles)},
+			},
+		})
+	}
+
+	return encodeXML(w, doc)
+}
+
+// gexfAttribute declares a GEXF attribute.
+type gexfAttribute struct {
+	ID    string `xml:"id,attr"`
+	Title string `xml:"title,attr"`
+	Type  string `xml:"type,attr"`
+}
+
+// gexfAttributes declares the attributes of a class of elements, either nodes or edges.
+type gexfAttributes struct {
+	Class      string          `xml:"class,attr"`
+	Attributes []gexfAttribute `xml:"attribute"`
+}
+
+// gexfValue is the value of a GEXF attribute.
+type gexfValue struct {
+	For   string `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// gexfNode is a GEXF node.
+type gexfNode struct {
+	ID     string      `xml:"id,attr"`
+	Label  string      `xml:"label,attr"`
+	Values []gexfValue `xml:"attvalues>attvalue"`
+}
+
+// gexfEdge is a GEXF edge.
+type gexfEdge struct {
+	ID     string      `xml:"id,attr"`
+	Source string      `xml:"source,attr"`
+	Target string      `xml:"target,attr"`
+	Values []gexfValue `xml:"attvalues>attvalue"`
+}
+
+// gexf is a GEXF document with a single static directed graph.
+type gexf struct {
+	XMLName xml.Name `xml:"gexf"`
+	XMLNS   string   `xml:"xmlns,attr"`
+	Version string   `xml:"version,attr"`
+	Graph   struct {
+		DefaultEdgeType string           `xml:"defaultedgetype,attr"`
+		Mode            string           `xml:"mode,attr"`
+		Attributes      []gexfAttributes `xml:"attributes"`
+		Nodes           []gexfNode       `xml:"nodes>node"`
+		Edges           []gexfEdge       `xml:"edges>edge"`
+	} `xml:"graph"`
+}
+
+// GEXF encodes the dependency graph as GEXF 1.3, for Gephi. Cookbooks and dependencies carry the same
+// attributes as in GraphML.
+func (h *Handler) GEXF(w io.Writer) error {
+	nodes, edges := h.exportGraph()
+
+	doc := gexf{XMLNS: "http://gexf.net/1.3", Version: "1.3"}
+	doc.Graph.DefaultEdgeType = "directed"
+	doc.Graph.Mode = "static"
+	doc.Graph.Attributes = []gexfAttributes{
+		{
+			Class: "node",
+			Attributes: []gexfAttribute{
+				{ID: "scc", Title: "scc", Type: "integer"},
+				{ID: "path", Title: "path", Type: "string"},
+				{ID: "version", Title: "version", Type: "string"},
+				{ID: "in_cycle", Title: "in_cycle", Type: "boolean"},
+				{ID: "missing", Title: "missing", Type: "boolean"},
+				{ID: "in_degree", Title: "in_degree", Type: "integer"},
+				{ID: "out_degree", Title: "out_degree", Type: "integer"},
+			},
+		},
+		{
+			Class: "edge",
+			Attributes: []gexfAttribute{
+				{ID: "constraint", Title: "constraint", Type: "string"},
+				{ID: "cycles", Title: "cycles", Type: "integer"},
+			},
+		},
+	}
+
+	for _, n := range nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, gexfNode{
+			ID:    n.name,
+			Label: n.name,
+			Values: []gexfValue{
+				{For: "scc", Value: strconv.Itoa(n.scc)},
+				{For: "path", Value: n.path},
+				{For: "version", Value: n.version},
+				{For: "in_cycle", Value: strconv.FormatBool(n.inCycle)},
+				{For: "missing", Value: strconv.FormatBool(n.missing)},
+				{For: "in_degree", Value: strconv.Itoa(n.inDegree)},
+				{For: "out_degree", Value: strconv.Itoa(n.outDegree)},
+			},
+		})
+	}
+
+	for i, e := range edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, gexfEdge{
+			ID:     strconv.Itoa(i),
+			Source: e.source,
+			Target: e.target,
+			Values: []gexfValue{
+				{For: "constraint", Value: e.constraint},
+				{For: "cycles", Value: strconv.Itoa(e.cycles)},
+			},
+		})
+	}
+
+	return encodeXML(w, doc)
+}
+
+// encodeXML writes v as an indented XML document.
+func encodeXML(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("failed encoding XML: %w", err)
+	}
+
+	_, err := io.WriteString(w, "\n")
+
+	return err
+}
@@ -0,0 +1,86 @@
+package whisk
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+	"testing/fstest"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/xlab/treeprint"
+)
+
+func TestHandlerExport(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"roles/web.json":              {Data: []byte(`{"name": "web", "run_list": ["recipe[app]"]}`)},
+		"cookbooks/app/metadata.rb":   {Data: []byte("name 'app'\nversion '1.0.0'\ndepends 'nginx', '~> 2.0'\n")},
+		"cookbooks/nginx/metadata.rb": {Data: []byte("name 'nginx'\nversion '2.1.0'\ndepends 'ssl'\n")},
+		"cookbooks/ssl/metadata.json": {Data: []byte(`{"name": "ssl", "version": "3.0.0", "dependencies": {"nginx": ">= 2.0"}}`)},
+	}
+
+	h := NewHandler([]string{"cookbooks"}, "roles", WithFS(fsys))
+	qt.Assert(t, h.WalkRole("web", treeprint.New()), qt.IsNil)
+	qt.Assert(t, h.FindSCCs(), qt.IsNil)
+	qt.Assert(t, h.FindCycles(), qt.IsNil)
+
+	t.Run("it should export graphml with node and edge attributes", func(t *testing.T) {
+		t.Parallel()
+		c := qt.New(t)
+
+		var b bytes.Buffer
+		c.Assert(h.GraphML(&b), qt.IsNil)
+
+		var doc graphML
+		c.Assert(xml.Unmarshal(b.Bytes(), &doc), qt.IsNil)
+		c.Assert(doc.Graph.Nodes[1], qt.DeepEquals, graphMLNode{
+			ID: "nginx",
+			Data: []graphMLData{
+				{Key: "label", Value: "nginx"},
+				{Key: "scc", Value: "0"},
+				{Key: "path", Value: "cookbooks/nginx"},
+				{Key: "version", Value: "2.1.0"},
+				{Key: "in_cycle", Value: "true"},
+				{Key: "missing", Value: "false"},
+				{Key: "in_degree", Value: "2"},
+				{Key: "out_degree", Value: "1"},
+			},
+		})
+		c.Assert(doc.Graph.Edges, qt.DeepEquals, []graphMLEdge{
+			{ID: "e0", Source: "app", Target: "nginx", Data: []graphMLData{{Key: "constraint", Value: "~> 2.0"}, {Key: "cycles", Value: "0"}}},
+			{ID: "e1", Source: "nginx", Target: "ssl", Data: []graphMLData{{Key: "constraint", Value: ""}, {Key: "cycles", Value: "1"}}},
+			{ID: "e2", Source: "ssl", Target: "nginx", Data: []graphMLData{{Key: "constraint", Value: ">= 2.0"}, {Key: "cycles", Value: "1"}}},
+		})
+	})
+
+	t.Run("it should export gexf with node and edge attributes", func(t *testing.T) {
+		t.Parallel()
+		c := qt.New(t)
+
+		var b bytes.Buffer
+		c.Assert(h.GEXF(&b), qt.IsNil)
+
+		var doc gexf
+		c.Assert(xml.Unmarshal(b.Bytes(), &doc), qt.IsNil)
+		c.Assert(doc.Graph.Nodes[0], qt.DeepEquals, gexfNode{
+			ID:    "app",
+			Label: "app",
+			Values: []gexfValue{
+				{For: "scc", Value: "-1"},
+				{For: "path", Value: "cookbooks/app"},
+				{For: "version", Value: "1.0.0"},
+				{For: "in_cycle", Value: "false"},
+				{For: "missing", Value: "false"},
+				{For: "in_degree", Value: "0"},
+				{For: "out_degree", Value: "1"},
+			},
+		})
+		c.Assert(doc.Graph.Edges[2], qt.DeepEquals, gexfEdge{
+			ID:     "2",
+			Source: "ssl",
+			Target: "nginx",
+			Values: []gexfValue{{For: "constraint", Value: ">= 2.0"}, {For: "cycles", Value: "1"}},
+		})
+	})
+}
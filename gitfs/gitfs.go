@@ -0,0 +1,354 @@
+// Package gitfs implements a read-only fs.FS over the tree of a git commit, reading objects
+// straight from the repository's object database so revisions don't need to be checked out.
+package gitfs
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"slack/whisk/internal/memfs"
+)
+
+// FS is a read-only filesystem serving the tree of a git commit. Symbolic links and
+// submodules are not supported and are left out of the tree.
+type FS struct {
+	// top is the repository's top-level directory.
+	top string
+	// prefix is the path of the directory FS was opened from, relative to top.
+	prefix string
+	// commit is the object name of the commit being served.
+	commit string
+	// entries indexes every file and directory in the commit's tree by their path.
+	entries map[string]*entry
+
+	// mu serializes access to the git cat-file process.
+	mu sync.Mutex
+	// catFile is a long-running `git cat-file --batch` process used to read blobs.
+	catFile *exec.Cmd
+	// stdin is where object names are requested to catFile.
+	stdin io.WriteCloser
+	// stdout is where catFile writes the requested objects to.
+	stdout *bufio.Reader
+}
+
+// entry is a file or directory in the commit's tree.
+type entry struct {
+	// Info describes the file or directory.
+	*memfs.Info
+	// object is the name of the git blob holding the file's content.
+	object string
+	// children holds the paths of a directory's entries, sorted by name.
+	children []string
+}
+
+// New returns a filesystem serving the tree of the git revision rev, as found in the repository
+// containing dir. Callers must call Close when done.
+func New(dir, rev string) (*FS, error) {
+	if rev == "" || strings.HasPrefix(rev, "-") {
+		return nil, fmt.Errorf("invalid git revision %q", rev)
+	}
+
+	top, err := git(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("failed finding git repository: %w", err)
+	}
+
+	prefix, err := git(dir, "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, fmt.Errorf("failed finding git repository: %w", err)
+	}
+
+	commit, err := git(dir, "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("unknown git revision %q: %w", rev, err)
+	}
+
+	f := &FS{
+		top:     strings.TrimSpace(top),
+		prefix:  strings.TrimSuffix(strings.TrimSpace(prefix), "/"),
+		commit:  strings.TrimSpace(commit),
+		entries: map[string]*entry{".": {Info: memfs.NewDirInfo(".")}},
+	}
+
+	if err := f.index(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Commit returns the object name of the commit being served.
+func (f *FS) Commit() string {
+	return f.commit
+}
+
+// Path translates a local path, absolute or relative to the directory FS was opened
+// from, into a path within the commit's tree.
+func (f *FS) Path(name string) (string, error) {
+	p := filepath.Join(f.prefix, name)
+	if filepath.IsAbs(name) {
+		rel, err := filepath.Rel(f.top, name)
+		if err != nil {
+			return "", fmt.Errorf("path %q is outside of the git repository: %w", name, err)
+		}
+		p = rel
+	}
+
+	p = filepath.ToSlash(filepath.Clean(p))
+	if p == ".." || strings.HasPrefix(p, "../") {
+		return "", fmt.Errorf("path %q is outside of the git repository", name)
+	}
+
+	return p, nil
+}
+
+// Open opens the named file or directory.
+func (f *FS) Open(name string) (fs.File, error) {
+	e, err := f.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.IsDir() {
+		return memfs.NewDir(name, f.children(e)), nil
+	}
+
+	data, err := f.read(e)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	return memfs.NewFile(name, e.Mode(), data), nil
+}
+
+// ReadFile reads the named file and returns its contents.
+func (f *FS) ReadFile(name string) ([]byte, error) {
+	e, err := f.lookup("read", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.IsDir() {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: errors.New("is a directory")}
+	}
+
+	data, err := f.read(e)
+	if err != nil {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: err}
+	}
+
+	return data, nil
+}
+
+// ReadDir reads the named directory and returns its entries sorted by name.
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	e, err := f.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if !e.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+
+	return f.children(e), nil
+}
+
+// children returns the entries of a directory, sorted by name.
+func (f *FS) children(e *entry) []fs.DirEntry {
+	entries := make([]fs.DirEntry, 0, len(e.children))
+	for _, p := range e.children {
+		entries = append(entries, f.entries[p].Info)
+	}
+
+	return entries
+}
+
+// Stat returns information about the named file or directory.
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	e, err := f.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.Info, nil
+}
+
+// Close terminates the git process used to read files, if any.
+func (f *FS) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.catFile == nil {
+		return nil
+	}
+
+	f.stdin.Close()
+	err := f.catFile.Wait()
+	f.catFile = nil
+
+	return err
+}
+
+// lookup returns the tree entry stored at name.
+func (f *FS) lookup(op, name string) (*entry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	e, ok := f.entries[name]
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+
+	return e, nil
+}
+
+// index loads the paths, modes, sizes and object names of the commit's entire tree.
+func (f *FS) index() error {
+	out, err := git(f.top, "ls-tree", "-r", "-t", "-z", "--long", "--full-tree", f.commit)
+	if err != nil {
+		return fmt.Errorf("failed listing tree of %s: %w", f.commit, err)
+	}
+
+	for _, record := range strings.Split(out, "\x00") {
+		if record == "" {
+			continue
+		}
+
+		// Records look like "<mode> SP <type> SP <object> SP+ <size> TAB <path>".
+		meta, p, ok := strings.Cut(record, "\t")
+		fields := strings.Fields(meta)
+		if !ok || len(fields) != 4 {
+			return fmt.Errorf("unexpected git ls-tree output: %q", record)
+		}
+
+		var info *memfs.Info
+		switch fields[1] {
+		case "tree":
+			info = memfs.NewDirInfo(path.Base(p))
+		case "blob":
+			if fields[0] == "120000" {
+				continue // symbolic link
+			}
+
+			mode := fs.FileMode(0o444)
+			if fields[0] == "100755" {
+				mode = 0o555
+			}
+
+			size, err := strconv.ParseInt(fields[3], 10, 64)
+			if err != nil {
+				return fmt.Errorf("unexpected git ls-tree output: %q", record)
+			}
+			info = memfs.NewInfo(path.Base(p), mode, size)
+		default:
+			continue // submodule
+		}
+
+		f.entries[p] = &entry{Info: info, object: fields[2]}
+		parent := f.entries[path.Dir(p)]
+		parent.children = append(parent.children, p)
+	}
+
+	for _, e := range f.entries {
+		sort.Strings(e.children)
+	}
+
+	return nil
+}
+
+// read returns the content of a file's blob, starting git cat-file if it isn't running yet.
+func (f *FS) read(e *entry) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.catFile == nil {
+		cmd := exec.Command("git", "cat-file", "--batch")
+		cmd.Dir = f.top
+
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			return nil, fmt.Errorf("failed starting git cat-file: %w", err)
+		}
+
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			return nil, fmt.Errorf("failed starting git cat-file: %w", err)
+		}
+
+		if err := cmd.Start(); err != nil {
+			return nil, fmt.Errorf("failed starting git cat-file: %w", err)
+		}
+
+		f.catFile, f.stdin, f.stdout = cmd, stdin, bufio.NewReader(stdout)
+	}
+
+	data, err := f.catBlob(e.object)
+	if err != nil {
+		// The process' output can't be trusted to be in sync anymore, so it's restarted on the next read.
+		f.stdin.Close()
+		f.catFile.Wait() //nolint:errcheck // the read error is more relevant.
+		f.catFile = nil
+
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// catBlob requests a blob to git cat-file and reads its content.
+func (f *FS) catBlob(object string) ([]byte, error) {
+	if _, err := fmt.Fprintln(f.stdin, object); err != nil {
+		return nil, fmt.Errorf("failed requesting object %s: %w", object, err)
+	}
+
+	// Responses look like "<object> SP <type> SP <size> LF <contents> LF".
+	header, err := f.stdout.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("failed reading object %s: %w", object, err)
+	}
+
+	fields := strings.Fields(header)
+	if len(fields) != 3 || fields[1] != "blob" {
+		return nil, fmt.Errorf("unexpected git cat-file output for %s: %q", object, header)
+	}
+
+	size, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("unexpected git cat-file output for %s: %q", object, header)
+	}
+
+	data := make([]byte, size+1)
+	if _, err := io.ReadFull(f.stdout, data); err != nil {
+		return nil, fmt.Errorf("failed reading object %s: %w", object, err)
+	}
+
+	return data[:size], nil
+}
+
+// git runs a git command in dir and returns its standard output.
+func git(dir string, args ...string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return string(out), nil
+}
@@ -0,0 +1,64 @@
+package gitfs
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestFS(t *testing.T) {
+	c := qt.New(t)
+	if _, err := exec.LookPath("git"); err != nil {
+		c.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"roles/base.json":              `{"name": "base", "run_list": ["recipe[apt]"]}`,
+		"cookbooks/apt/metadata.rb":    "name 'apt'\n",
+		"cookbooks/apt/recipes/a.rb":   "",
+		"cookbooks/users/metadata.rb":  "name 'users'\ndepends 'apt'\n",
+		"cookbooks/users/recipes/a.rb": "package 'zsh'\n",
+	}
+
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		c.Assert(os.MkdirAll(filepath.Dir(p), 0o755), qt.IsNil)
+		c.Assert(os.WriteFile(p, []byte(content), 0o644), qt.IsNil)
+	}
+
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "-A"},
+		{"-c", "user.name=whisk", "-c", "user.email=whisk@example.com", "commit", "-q", "-m", "initial"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		c.Assert(err, qt.IsNil, qt.Commentf("%s", out))
+	}
+
+	// Uncommitted changes must not be visible.
+	c.Assert(os.WriteFile(filepath.Join(dir, "cookbooks/apt/metadata.rb"), []byte("changed"), 0o644), qt.IsNil)
+
+	fsys, err := New(filepath.Join(dir, "cookbooks"), "HEAD")
+	c.Assert(err, qt.IsNil)
+	defer fsys.Close()
+
+	c.Assert(fstest.TestFS(fsys, "roles/base.json", "cookbooks/apt/metadata.rb", "cookbooks/users/recipes/a.rb"), qt.IsNil)
+
+	data, err := fsys.ReadFile("cookbooks/apt/metadata.rb")
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(data), qt.Equals, "name 'apt'\n")
+
+	p, err := fsys.Path("users/metadata.rb")
+	c.Assert(err, qt.IsNil)
+	c.Assert(p, qt.Equals, "cookbooks/users/metadata.rb")
+
+	_, err = fsys.Path("../../outside")
+	c.Assert(err, qt.IsNotNil)
+}
@@ -0,0 +1,45 @@
+package cycle
+
+// Participation holds how many distinct cycles go through each vertex and edge of a graph.
+type Participation struct {
+	// Vertices maps every vertex to the number of cycles going through it.
+	Vertices map[string]int
+	// Edges maps every edge, by its source and target vertices, to the number of cycles going through it.
+	Edges map[string]map[string]int
+}
+
+// Count tallies the cycles, as returned by Tarjan.Find, going through every vertex and edge of g.
+// Vertices and edges not participating in any cycle are accounted for with a count of zero.
+func Count(g map[string][]string, cycles [][]string) Participation {
+	p := Participation{
+		Vertices: make(map[string]int),
+		Edges:    make(map[string]map[string]int),
+	}
+
+	for v, edges := range g {
+		p.Vertices[v] += 0
+		if _, ok := p.Edges[v]; !ok {
+			p.Edges[v] = make(map[string]int)
+		}
+
+		for _, w := range edges {
+			p.Vertices[w] += 0
+			p.Edges[v][w] += 0
+		}
+	}
+
+	for _, c := range cycles {
+		// The first vertex of a cycle is repeated at its end to close it.
+		for i := 0; i < len(c)-1; i++ {
+			v, w := c[i], c[i+1]
+			p.Vertices[v]++
+
+			if _, ok := p.Edges[v]; !ok {
+				p.Edges[v] = make(map[string]int)
+			}
+			p.Edges[v][w]++
+		}
+	}
+
+	return p
+}
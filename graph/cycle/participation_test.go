@@ -0,0 +1,50 @@
+package cycle
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestCount(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		g        map[string][]string
+		cycles   [][]string
+		expected Participation
+	}{
+		{
+			"it should count cycles going through every vertex and edge",
+			map[string][]string{
+				"1": {"2"},
+				"2": {"1", "3"},
+				"3": {"2", "4"},
+				"4": {},
+			},
+			[][]string{
+				{"1", "2", "1"},
+				{"2", "3", "2"},
+			},
+			Participation{
+				Vertices: map[string]int{"1": 1, "2": 2, "3": 1, "4": 0},
+				Edges: map[string]map[string]int{
+					"1": {"2": 1},
+					"2": {"1": 1, "3": 1},
+					"3": {"2": 1, "4": 0},
+					"4": {},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := qt.New(t)
+			c.Assert(Count(tt.g, tt.cycles), qt.DeepEquals, tt.expected)
+		})
+	}
+}
@@ -0,0 +1,404 @@
+package layout
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+const (
+	// layerGap is the vertical space between layers.
+	layerGap = 60
+	// vertexGap is the horizontal space between vertices of the same layer.
+	vertexGap = 30
+	// dummyGap is the horizontal space taken by the bends of edges crossing layers.
+	dummyGap = 10
+	// sweeps is the number of down and up passes reordering layers to reduce crossings.
+	sweeps = 8
+	// relaxations is the number of passes moving vertices towards their neighbors.
+	relaxations = 8
+)
+
+// Size is the size of a vertex's box.
+type Size struct {
+	Width  float64
+	Height float64
+}
+
+// Point is a position in the layout.
+type Point struct {
+	X float64
+	Y float64
+}
+
+// Rect is a vertex's box in the layout, positioned by its top left corner.
+type Rect struct {
+	X      float64
+	Y      float64
+	Width  float64
+	Height float64
+}
+
+// Layout is the result of laying out a graph: where every vertex's box goes, and the points every
+// edge goes through, from the bottom of its source to the top of its target.
+type Layout struct {
+	// Width and Height are the size of the whole layout.
+	Width  float64
+	Height float64
+	// Vertices maps every vertex to its box.
+	Vertices map[string]Rect
+	// Edges maps every {from, to} edge to the points it goes through.
+	Edges map[[2]string][]Point
+}
+
+// node is a vertex being laid out. Edges crossing more than one layer go through dummy nodes,
+// one per layer crossed, so they bend around vertices instead of going through them.
+type node struct {
+	vertex string
+	dummy  bool
+	size   Size
+	layer  int
+	x      float64
+	// up and down are the nodes this node is connected to in the previous and next layers.
+	up, down []*node
+}
+
+// Sugiyama lays out directed acyclic graphs in layers, following Sugiyama's framework: vertices are
+// assigned to layers so every edge points downwards, layers are reordered to reduce edge crossings,
+// and vertices are then moved towards the vertices they're connected to.
+//
+// Cyclic graphs must be condensed first, contracting every strongly connected component into a single vertex.
+type Sugiyama struct {
+	// G is the graph's adjencency list
+	G map[string][]string
+	// size returns the size of a vertex's box.
+	size func(v string) Size
+	// nodes holds the node of every vertex.
+	nodes map[string]*node
+	// layers holds the nodes of every layer, in order.
+	layers [][]*node
+	// edges holds the nodes every edge goes through, from source to target.
+	edges map[[2]string][]*node
+}
+
+// NewSugiyama initializes and returns a Sugiyama instance for laying out the graph g, sizing every
+// vertex's box with size.
+func NewSugiyama(g map[string][]string, size func(v string) Size) *Sugiyama {
+	return &Sugiyama{
+		G:     g,
+		size:  size,
+		nodes: make(map[string]*node),
+		edges: make(map[[2]string][]*node),
+	}
+}
+
+// Layout lays out the graph, returning an error when it isn't acyclic.
+func (s *Sugiyama) Layout() (*Layout, error) {
+	if s.G == nil {
+		return nil, fmt.Errorf("no graph found")
+	}
+
+	if err := s.assignLayers(); err != nil {
+		return nil, err
+	}
+
+	s.addDummies()
+	s.reduceCrossings()
+	s.placeVertices()
+
+	return s.result(), nil
+}
+
+// assignLayers places every vertex in the layer right below its lowest predecessor, walking the
+// graph in topological order. Sources go to the first layer.
+func (s *Sugiyama) assignLayers() error {
+	vertices := sortKeys(s.G)
+	indegree := make(map[string]int, len(vertices))
+	for _, v := range vertices {
+		if _, ok := s.nodes[v]; !ok {
+			s.nodes[v] = &node{vertex: v, size: s.size(v)}
+		}
+		for _, w := range dedup(s.G[v]) {
+			if _, ok := s.nodes[w]; !ok {
+				s.nodes[w] = &node{vertex: w, size: s.size(w)}
+			}
+			indegree[w]++
+		}
+	}
+
+	var queue []string
+	for _, v := range s.vertices() {
+		if indegree[v] == 0 {
+			queue = append(queue, v)
+		}
+	}
+
+	visited := 0
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		visited++
+
+		for _, w := range dedup(s.G[v]) {
+			if s.nodes[w].layer < s.nodes[v].layer+1 {
+				s.nodes[w].layer = s.nodes[v].layer + 1
+			}
+
+			indegree[w]--
+			if indegree[w] == 0 {
+				queue = append(queue, w)
+			}
+		}
+	}
+
+	if visited != len(s.nodes) {
+		return fmt.Errorf("unable to lay out a cyclic graph, condense it first")
+	}
+
+	for _, v := range s.vertices() {
+		n := s.nodes[v]
+		for len(s.layers) <= n.layer {
+			s.layers = append(s.layers, nil)
+		}
+		s.layers[n.layer] = append(s.layers[n.layer], n)
+	}
+
+	return nil
+}
+
+// addDummies connects the nodes of every edge, adding a dummy node for every layer the edge crosses.
+func (s *Sugiyama) addDummies() {
+	for _, v := range sortKeys(s.G) {
+		for _, w := range dedup(s.G[v]) {
+			from, to := s.nodes[v], s.nodes[w]
+
+			path := []*node{from}
+			for l := from.layer + 1; l < to.layer; l++ {
+				d := &node{dummy: true, layer: l}
+				s.layers[l] = append(s.layers[l], d)
+				path = append(path, d)
+			}
+			path = append(path, to)
+
+			for i := 0; i < len(path)-1; i++ {
+				path[i].down = append(path[i].down, path[i+1])
+				path[i+1].up = append(path[i+1].up, path[i])
+			}
+
+			s.edges[[2]string{v, w}] = path
+		}
+	}
+}
+
+// reduceCrossings reorders the layers using the barycenter heuristic: sweeping down and up, every
+// node is moved to the average position of the nodes it's connected to in the layer just swept.
+func (s *Sugiyama) reduceCrossings() {
+	s.index()
+	for i := 0; i < sweeps; i++ {
+		for l := 1; l < len(s.layers); l++ {
+			s.reorder(s.layers[l], func(n *node) []*node { return n.up })
+		}
+		for l := len(s.layers) - 2; l >= 0; l-- {
+			s.reorder(s.layers[l], func(n *node) []*node { return n.down })
+		}
+	}
+}
+
+// reorder sorts the layer by the barycenter of every node's neighbors. Nodes without neighbors keep
+// their position.
+func (s *Sugiyama) reorder(layer []*node, neighbors func(*node) []*node) {
+	barycenter := make(map[*node]float64, len(layer))
+	for _, n := range layer {
+		barycenter[n] = n.x
+		if adj := neighbors(n); len(adj) > 0 {
+			sum := 0.0
+			for _, m := range adj {
+				sum += m.x
+			}
+			barycenter[n] = sum / float64(len(adj))
+		}
+	}
+
+	sort.SliceStable(layer, func(i, j int) bool {
+		return barycenter[layer[i]] < barycenter[layer[j]]
+	})
+
+	for i, n := range layer {
+		n.x = float64(i)
+	}
+}
+
+// index sets every node's position to its index within its layer, as used while reordering layers.
+func (s *Sugiyama) index() {
+	for _, layer := range s.layers {
+		for i, n := range layer {
+			n.x = float64(i)
+		}
+	}
+}
+
+// placeVertices packs every layer from left to right, and then moves nodes towards the average
+// position of their neighbors without changing the layers' order.
+func (s *Sugiyama) placeVertices() {
+	for _, layer := range s.layers {
+		x := 0.0
+		for _, n := range layer {
+			x += s.gap(n) + n.size.Width/2
+			n.x = x
+			x += n.size.Width / 2
+		}
+	}
+
+	for i := 0; i < relaxations; i++ {
+		for _, layer := range s.layers {
+			for _, n := range layer {
+				adj := append(append([]*node(nil), n.up...), n.down...)
+				if len(adj) == 0 {
+					continue
+				}
+
+				sum := 0.0
+				for _, m := range adj {
+					sum += m.x
+				}
+				n.x = sum / float64(len(adj))
+			}
+			s.separate(layer)
+		}
+	}
+
+	minX := math.Inf(1)
+	for _, layer := range s.layers {
+		if len(layer) > 0 {
+			minX = math.Min(minX, layer[0].x-layer[0].size.Width/2-s.gap(layer[0]))
+		}
+	}
+
+	for _, layer := range s.layers {
+		for _, n := range layer {
+			n.x -= minX
+		}
+	}
+}
+
+// separate removes overlaps between the nodes of a layer, keeping their order, by pushing them
+// rightwards and then leftwards around their mean shift.
+func (s *Sugiyama) separate(layer []*node) {
+	if len(layer) == 0 {
+		return
+	}
+
+	desired := make([]float64, len(layer))
+	for i, n := range layer {
+		desired[i] = n.x
+	}
+
+	for i := 1; i < len(layer); i++ {
+		prev, n := layer[i-1], layer[i]
+		if least := prev.x + prev.size.Width/2 + s.gap(n) + n.size.Width/2; n.x < least {
+			n.x = least
+		}
+	}
+
+	shift := 0.0
+	for i, n := range layer {
+		shift += n.x - desired[i]
+	}
+	shift /= float64(len(layer))
+
+	for _, n := range layer {
+		n.x -= shift
+	}
+}
+
+// gap returns the horizontal space left before the node.
+func (s *Sugiyama) gap(n *node) float64 {
+	if n.dummy {
+		return dummyGap
+	}
+
+	return vertexGap
+}
+
+// result returns the layout, stacking layers from top to bottom.
+func (s *Sugiyama) result() *Layout {
+	l := &Layout{
+		Vertices: make(map[string]Rect, len(s.nodes)),
+		Edges:    make(map[[2]string][]Point, len(s.edges)),
+	}
+
+	y := make(map[*node]float64)
+	top := float64(layerGap) / 2
+	for _, layer := range s.layers {
+		height := 0.0
+		for _, n := range layer {
+			height = math.Max(height, n.size.Height)
+			l.Width = math.Max(l.Width, n.x+n.size.Width/2+s.gap(n))
+		}
+
+		for _, n := range layer {
+			y[n] = top + height/2
+			if !n.dummy {
+				l.Vertices[n.vertex] = Rect{
+					X:      n.x - n.size.Width/2,
+					Y:      y[n] - n.size.Height/2,
+					Width:  n.size.Width,
+					Height: n.size.Height,
+				}
+			}
+		}
+
+		top += height + layerGap
+	}
+	l.Height = top - float64(layerGap)/2
+
+	for e, path := range s.edges {
+		from, to := path[0], path[len(path)-1]
+
+		points := []Point{{X: from.x, Y: y[from] + from.size.Height/2}}
+		for _, d := range path[1 : len(path)-1] {
+			points = append(points, Point{X: d.x, Y: y[d]})
+		}
+		points = append(points, Point{X: to.x, Y: y[to] - to.size.Height/2})
+
+		l.Edges[e] = points
+	}
+
+	return l
+}
+
+// vertices returns every vertex, including those only found as dependencies, sorted alphabetically.
+func (s *Sugiyama) vertices() []string {
+	vertices := make([]string, 0, len(s.nodes))
+	for v := range s.nodes {
+		vertices = append(vertices, v)
+	}
+	sort.Strings(vertices)
+
+	return vertices
+}
+
+// dedup returns the vertices without repetitions, preserving their order.
+func dedup(vertices []string) []string {
+	seen := make(map[string]bool, len(vertices))
+	out := make([]string, 0, len(vertices))
+	for _, v := range vertices {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+
+	return out
+}
+
+// sortKeys sorts the map's keys alphabetically.
+func sortKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
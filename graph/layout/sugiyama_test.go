@@ -0,0 +1,114 @@
+package layout
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestSugiyamaLayout(t *testing.T) {
+	t.Parallel()
+
+	size := func(v string) Size { return Size{Width: 40, Height: 20} }
+
+	tests := []struct {
+		name   string
+		g      map[string][]string
+		layers map[string]float64
+		bends  map[[2]string]int
+	}{
+		{
+			"it should place dependencies below the vertices depending on them",
+			map[string][]string{
+				"a": {"b"},
+				"b": {"c"},
+				"c": {},
+			},
+			map[string]float64{"a": 30, "b": 110, "c": 190},
+			map[[2]string]int{{"a", "b"}: 0, {"b", "c"}: 0},
+		},
+		{
+			"it should place vertices below their lowest dependent",
+			map[string][]string{
+				"a": {"b", "c"},
+				"b": {"c"},
+			},
+			map[string]float64{"a": 30, "b": 110, "c": 190},
+			map[[2]string]int{{"a", "b"}: 0, {"a", "c"}: 1, {"b", "c"}: 0},
+		},
+		{
+			"it should place unconnected vertices in the first layer",
+			map[string][]string{
+				"a": {},
+				"b": {"c"},
+			},
+			map[string]float64{"a": 30, "b": 30, "c": 110},
+			map[[2]string]int{{"b", "c"}: 0},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := qt.New(t)
+
+			l, err := NewSugiyama(tt.g, size).Layout()
+			c.Assert(err, qt.IsNil)
+
+			layers := make(map[string]float64)
+			for v, r := range l.Vertices {
+				layers[v] = r.Y
+				c.Assert(r.X >= 0 && r.X+r.Width <= l.Width, qt.IsTrue, qt.Commentf("vertex %s out of bounds", v))
+				c.Assert(r.Y+r.Height <= l.Height, qt.IsTrue, qt.Commentf("vertex %s out of bounds", v))
+			}
+			c.Assert(layers, qt.DeepEquals, tt.layers)
+
+			bends := make(map[[2]string]int)
+			for e, points := range l.Edges {
+				bends[e] = len(points) - 2
+
+				from, to := l.Vertices[e[0]], l.Vertices[e[1]]
+				c.Assert(points[0], qt.Equals, Point{X: from.X + from.Width/2, Y: from.Y + from.Height})
+				c.Assert(points[len(points)-1], qt.Equals, Point{X: to.X + to.Width/2, Y: to.Y})
+			}
+			c.Assert(bends, qt.DeepEquals, tt.bends)
+		})
+	}
+}
+
+func TestSugiyamaLayoutOverlaps(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+
+	g := map[string][]string{
+		"a": {"d", "e", "f", "g"},
+		"b": {"d", "g"},
+		"c": {"e", "f"},
+	}
+
+	l, err := NewSugiyama(g, func(v string) Size { return Size{Width: 50, Height: 20} }).Layout()
+	c.Assert(err, qt.IsNil)
+
+	for v, r := range l.Vertices {
+		for w, s := range l.Vertices {
+			if v == w || r.Y != s.Y {
+				continue
+			}
+			c.Assert(r.X+r.Width <= s.X || s.X+s.Width <= r.X, qt.IsTrue, qt.Commentf("%s overlaps %s", v, w))
+		}
+	}
+}
+
+func TestSugiyamaLayoutCycles(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+
+	g := map[string][]string{
+		"a": {"b"},
+		"b": {"a"},
+	}
+
+	_, err := NewSugiyama(g, func(v string) Size { return Size{} }).Layout()
+	c.Assert(err, qt.ErrorMatches, "unable to lay out a cyclic graph, condense it first")
+}
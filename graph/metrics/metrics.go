@@ -0,0 +1,219 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+
+	"slack/whisk/graph/cycle"
+	"slack/whisk/graph/scc"
+)
+
+// Vertex holds the metrics computed for a single vertex of the graph.
+type Vertex struct {
+	// Name is the vertex identifier.
+	Name string `json:"name"`
+	// InDegree is the number of vertices depending on this vertex.
+	InDegree int `json:"in_degree"`
+	// OutDegree is the number of vertices this vertex depends on.
+	OutDegree int `json:"out_degree"`
+	// Closure is the number of vertices reachable from this vertex, itself excluded.
+	Closure int `json:"closure"`
+	// Depth is the length of the longest path from this vertex in the graph's condensation.
+	Depth int `json:"depth"`
+	// Betweenness is the number of shortest paths between other vertices going through this vertex.
+	Betweenness float64 `json:"betweenness"`
+	// Cycles is the number of distinct cycles this vertex participates in.
+	Cycles int `json:"cycles"`
+}
+
+// Calculator computes degree, reachability, depth and centrality metrics for every
+// vertex in a graph.
+type Calculator struct {
+	// G is the graph's adjencency list
+	G map[string][]string
+	// cycles are the distinct cycles found in G, used to rank vertices by participation.
+	cycles [][]string
+	// vertices holds the metrics computed so far, keyed by vertex name.
+	vertices map[string]*Vertex
+}
+
+// NewCalculator initializes and returns a Calculator instance for the graph g and
+// the distinct cycles previously found in it.
+func NewCalculator(g map[string][]string, cycles [][]string) *Calculator {
+	return &Calculator{
+		G:        g,
+		cycles:   cycles,
+		vertices: make(map[string]*Vertex),
+	}
+}
+
+// Compute returns the metrics of every vertex, ranked by the number of cycles they
+// participate in, their betweenness centrality and, lastly, their name.
+func (c *Calculator) Compute() ([]Vertex, error) {
+	if c.G == nil {
+		return nil, fmt.Errorf("no graph found")
+	}
+
+	for _, v := range sortKeys(c.G) {
+		c.vertex(v)
+		for _, w := range c.G[v] {
+			c.vertex(v).OutDegree++
+			c.vertex(w).InDegree++
+		}
+	}
+
+	for v := range c.vertices {
+		c.vertex(v).Closure = len(c.reachable(v)) - 1
+	}
+
+	if err := c.depths(); err != nil {
+		return nil, err
+	}
+
+	c.betweenness()
+
+	for v, n := range cycle.Count(c.G, c.cycles).Vertices {
+		c.vertex(v).Cycles = n
+	}
+
+	ranking := make([]Vertex, 0, len(c.vertices))
+	for _, v := range c.vertices {
+		ranking = append(ranking, *v)
+	}
+
+	sort.Slice(ranking, func(i, j int) bool {
+		a, b := ranking[i], ranking[j]
+		switch {
+		case a.Cycles != b.Cycles:
+			return a.Cycles > b.Cycles
+		case a.Betweenness != b.Betweenness:
+			return a.Betweenness > b.Betweenness
+		default:
+			return a.Name < b.Name
+		}
+	})
+
+	return ranking, nil
+}
+
+// vertex returns the metrics of v, initializing them if needed.
+func (c *Calculator) vertex(v string) *Vertex {
+	if _, ok := c.vertices[v]; !ok {
+		c.vertices[v] = &Vertex{Name: v}
+	}
+
+	return c.vertices[v]
+}
+
+// reachable returns the set of vertices reachable from v, including v, using breadth-first search.
+func (c *Calculator) reachable(v string) map[string]bool {
+	seen := map[string]bool{v: true}
+	queue := []string{v}
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+		for _, w := range c.G[u] {
+			if !seen[w] {
+				seen[w] = true
+				queue = append(queue, w)
+			}
+		}
+	}
+
+	return seen
+}
+
+// depths computes the longest path from each vertex over the graph's condensation, since
+// finding longest simple paths in graphs with cycles is NP-hard. Vertices in the same
+// strongly connected component share the same depth.
+func (c *Calculator) depths() error {
+	sccs, err := scc.NewTarjan(c.G).Find()
+	if err != nil {
+		return fmt.Errorf("failed condensing graph: %w", err)
+	}
+
+	component := make(map[string]int)
+	for i, s := range sccs {
+		for _, v := range s {
+			component[v] = i
+		}
+	}
+
+	// Tarjan's algorithm returns components in reverse topological order, so the
+	// depth of every successor is known by the time a component is visited.
+	depth := make([]int, len(sccs))
+	for i, s := range sccs {
+		for _, v := range s {
+			for _, w := range c.G[v] {
+				if j := component[w]; j != i && depth[j]+1 > depth[i] {
+					depth[i] = depth[j] + 1
+				}
+			}
+		}
+
+		for _, v := range s {
+			c.vertex(v).Depth = depth[i]
+		}
+	}
+
+	return nil
+}
+
+// betweenness computes betweenness centrality using Brandes' algorithm for unweighted graphs:
+// https://www.eecs.wsu.edu/~assefaw/CptS580-06/papers/brandes01centrality.pdf
+func (c *Calculator) betweenness() {
+	vertices := make([]string, 0, len(c.vertices))
+	for v := range c.vertices {
+		vertices = append(vertices, v)
+	}
+	sort.Strings(vertices)
+
+	for _, s := range vertices {
+		var stack []string
+		preds := make(map[string][]string)
+		sigma := map[string]float64{s: 1}
+		dist := map[string]int{s: 0}
+
+		queue := []string{s}
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			stack = append(stack, v)
+
+			for _, w := range c.G[v] {
+				if _, ok := dist[w]; !ok {
+					dist[w] = dist[v] + 1
+					queue = append(queue, w)
+				}
+
+				if dist[w] == dist[v]+1 {
+					sigma[w] += sigma[v]
+					preds[w] = append(preds[w], v)
+				}
+			}
+		}
+
+		delta := make(map[string]float64)
+		for i := len(stack) - 1; i >= 0; i-- {
+			w := stack[i]
+			for _, v := range preds[w] {
+				delta[v] += sigma[v] / sigma[w] * (1 + delta[w])
+			}
+
+			if w != s {
+				c.vertex(w).Betweenness += delta[w]
+			}
+		}
+	}
+}
+
+// sortKeys sorts the map's keys alphabetically.
+func sortKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestCalculatorCompute(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		g        map[string][]string
+		cycles   [][]string
+		expected []Vertex
+	}{
+		{
+			"it should rank vertices participating in cycles first",
+			map[string][]string{
+				"a": {"b"},
+				"b": {"c"},
+				"c": {"b", "d"},
+				"d": {},
+			},
+			[][]string{
+				{"b", "c", "b"},
+			},
+			[]Vertex{
+				{Name: "b", InDegree: 2, OutDegree: 1, Closure: 2, Depth: 1, Betweenness: 2, Cycles: 1},
+				{Name: "c", InDegree: 1, OutDegree: 2, Closure: 2, Depth: 1, Betweenness: 2, Cycles: 1},
+				{Name: "a", InDegree: 0, OutDegree: 1, Closure: 3, Depth: 2, Betweenness: 0, Cycles: 0},
+				{Name: "d", InDegree: 1, OutDegree: 0, Closure: 0, Depth: 0, Betweenness: 0, Cycles: 0},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := qt.New(t)
+			vertices, err := NewCalculator(tt.g, tt.cycles).Compute()
+			c.Assert(err, qt.IsNil, qt.Commentf("err should be nil: %v", err))
+			c.Assert(vertices, qt.DeepEquals, tt.expected)
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package reduction
+
+import (
+	"fmt"
+	"sort"
+
+	"slack/whisk/graph/scc"
+)
+
+// Reducer computes the transitive reduction of a graph's condensation, as described by
+// Aho, Garey and Ullman: https://epubs.siam.org/doi/10.1137/0201008
+//
+// Cyclic graphs don't have a unique transitive reduction, so every strongly connected
+// component is contracted into a single vertex first, turning the graph into a DAG.
+// Edges between vertices of the same strongly connected component are never reported.
+type Reducer struct {
+	// G is the graph's adjencency list
+	G map[string][]string
+	// component maps every vertex to the strongly connected component it belongs to.
+	component map[string]int
+	// dag is the condensation of G, keyed by strongly connected component.
+	dag map[int]map[int]bool
+	// reach memoizes the components reachable from each component in the condensation.
+	reach map[int]map[int]bool
+}
+
+// NewReducer initializes and returns a Reducer instance for finding redundant
+// edges in a graph.
+func NewReducer(g map[string][]string) *Reducer {
+	return &Reducer{
+		G:         g,
+		component: make(map[string]int),
+		dag:       make(map[int]map[int]bool),
+		reach:     make(map[int]map[int]bool),
+	}
+}
+
+// Find returns the edges, as {from, to} pairs, that can be removed from the graph
+// without changing which vertices are reachable from each other. Removing all of them
+// at once is also safe.
+func (r *Reducer) Find() ([][]string, error) {
+	if r.G == nil {
+		return nil, fmt.Errorf("no graph found")
+	}
+
+	sccs, err := scc.NewTarjan(r.G).Find()
+	if err != nil {
+		return nil, fmt.Errorf("failed condensing graph: %w", err)
+	}
+
+	for i, c := range sccs {
+		for _, v := range c {
+			r.component[v] = i
+		}
+	}
+
+	// kept tracks the first edge seen between two components. Any other edge joining the
+	// same pair of components is parallel to it and therefore redundant.
+	kept := make(map[int]map[int]bool)
+	var redundant [][]string
+	for _, u := range sortKeys(r.G) {
+		seen := make(map[string]bool)
+		for _, v := range r.G[u] {
+			cu, cv := r.component[u], r.component[v]
+			if cu == cv || seen[v] {
+				continue
+			}
+			seen[v] = true
+
+			if _, ok := kept[cu]; !ok {
+				kept[cu] = make(map[int]bool)
+				r.dag[cu] = make(map[int]bool)
+			}
+
+			if kept[cu][cv] {
+				redundant = append(redundant, []string{u, v})
+				continue
+			}
+			kept[cu][cv] = true
+			r.dag[cu][cv] = true
+		}
+	}
+
+	for _, u := range sortKeys(r.G) {
+		for _, v := range dedup(r.G[u]) {
+			cu, cv := r.component[u], r.component[v]
+			if cu == cv || !r.implied(cu, cv) {
+				continue
+			}
+			redundant = append(redundant, []string{u, v})
+		}
+	}
+
+	sort.Slice(redundant, func(i, j int) bool {
+		if redundant[i][0] != redundant[j][0] {
+			return redundant[i][0] < redundant[j][0]
+		}
+		return redundant[i][1] < redundant[j][1]
+	})
+
+	return unique(redundant), nil
+}
+
+// implied reports whether component b is reachable from component a through
+// a path other than the direct edge between them.
+func (r *Reducer) implied(a, b int) bool {
+	for c := range r.dag[a] {
+		if c != b && r.reachable(c)[b] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// reachable returns the set of components reachable from c in the condensation.
+func (r *Reducer) reachable(c int) map[int]bool {
+	if reach, ok := r.reach[c]; ok {
+		return reach
+	}
+
+	reach := make(map[int]bool)
+	for w := range r.dag[c] {
+		reach[w] = true
+		for x := range r.reachable(w) {
+			reach[x] = true
+		}
+	}
+	r.reach[c] = reach
+
+	return reach
+}
+
+// dedup returns the vertices without repetitions, preserving their order.
+func dedup(vertices []string) []string {
+	seen := make(map[string]bool, len(vertices))
+	out := make([]string, 0, len(vertices))
+	for _, v := range vertices {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+
+	return out
+}
+
+// unique removes consecutive duplicated edges from a sorted list of edges.
+func unique(edges [][]string) [][]string {
+	var out [][]string
+	for i, e := range edges {
+		if i > 0 && e[0] == edges[i-1][0] && e[1] == edges[i-1][1] {
+			continue
+		}
+		out = append(out, e)
+	}
+
+	return out
+}
+
+// sortKeys sorts the map's keys alphabetically.
+func sortKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
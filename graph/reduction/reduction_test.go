@@ -0,0 +1,67 @@
+package reduction
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestReducerFind(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		g        map[string][]string
+		expected [][]string
+	}{
+		{
+			"it should find edges implied by longer paths",
+			map[string][]string{
+				"a": {"b", "c", "d"},
+				"b": {"c"},
+				"c": {"d"},
+				"d": {},
+			},
+			[][]string{
+				{"a", "c"},
+				{"a", "d"},
+			},
+		},
+		{
+			"it should reduce the condensation of cyclic graphs",
+			map[string][]string{
+				"a": {"b", "d"},
+				"b": {"c"},
+				"c": {"b", "d"},
+				"d": {},
+				"e": {"b", "c"},
+			},
+			[][]string{
+				{"a", "d"},
+				{"e", "c"},
+			},
+		},
+		{
+			"it should not find redundant edges in a reduced graph",
+			map[string][]string{
+				"1": {"2", "3"},
+				"2": {"4"},
+				"3": {"4"},
+				"4": {"1"},
+				"5": {},
+			},
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := qt.New(t)
+			redundant, err := NewReducer(tt.g).Find()
+			c.Assert(err, qt.IsNil, qt.Commentf("err should be nil: %v", err))
+			c.Assert(redundant, qt.DeepEquals, tt.expected)
+		})
+	}
+}
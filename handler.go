@@ -1,131 +1,169 @@
 package whisk
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
-	"path/filepath"
 	"sort"
 	"strings"
-	"text/template"
 
 	"slack/whisk/chef"
 	"slack/whisk/graph/cycle"
+	"slack/whisk/graph/metrics"
+	"slack/whisk/graph/reduction"
 	"slack/whisk/graph/scc"
 
 	"github.com/xlab/treeprint"
 )
 
-// graphviz dot template
-const dotTpl = `
-digraph g {
-	bgcolor = "#ffffff"
-	splines = ortho
-	overlap = true
-	newrank = true
-
-	node [
-		shape = rectangle,
-		width = 0.25,
-		color = "#323538",
-		fillcolor = white,
-		style = "filled, solid",
-		fontcolor = "#323538",
-		fontsize = 8,
-	]
-
-	edge [
-		penwidth = 0.50,
-		color = "#323538",
-		arrowhead = "vee"
-	]
-	{{ range $index, $value := .Sccs }}
-	subgraph cluster_sccs{{ $index }} {
-		style = "filled, solid";
-		color = "#F2C744";
-		label = "Strongly Connected Subgraph {{ $index }}";
-
-		{{ range $value -}}
-		{{ replace . "-" "_" }} [color = "#F2C744"];
-		{{ end }}
-	}
-	{{ end -}}
-
-	{{- range $vertex, $edges := .G -}}
-	{{ replace $vertex "-" "_" }} -> { {{ range $edges }} {{ replace . "-" "_" }} {{ end }} }
-	{{ end }}
-}
-`
-
 // Handler is, guess what, the whisk's handler! It loads Chef's dependency graph and
 // finds strongly connected components as well as distinct cycles. Offering multiple
 // output formats to display the information.
 type Handler struct {
+	// fsys is the filesystem roles and cookbooks are loaded from.
+	fsys fs.FS
 	// cookbookPaths is the list of directories where cookbooks are kept.
 	cookbookPaths []string
 	// rolesPath is the directory where the roles are stored.
 	rolesPath string
+	// include and exclude are glob patterns selecting which role files are loaded.
+	include, exclude []string
 	// rolesIndex keeps the entire roles population in memory for lookups.
 	// This is because role names don't necessarily match their file names.
 	rolesIndex map[string]*chef.Role
+	// runList holds the run list entries walked, as given to WalkRole or WalkRunList.
+	runList []string
+	// walkedRoles holds the names of the roles walked, in the order they were first found.
+	walkedRoles []string
+	// allowMissing makes missing cookbooks be recorded instead of aborting the walk.
+	allowMissing bool
+	// missing holds the cookbooks whose metadata wasn't found, when allowMissing is set.
+	missing map[string]bool
+	// violations contains the role validation rules not met by the roles walked.
+	violations []Violation
 	// sccs holds the subgraphs of unique strongly connected components found.
 	sccs [][]string
 	// graph contains the unmodified directed graph, as found in roles and cookbooks.
 	graph map[string][]string
+	// cookbooks holds the cookbooks walked, to locate where their dependencies are declared.
+	cookbooks map[string]*chef.Cookbook
 	// cycles contains the distinct cycles found in the dependency graph.
 	cycles [][]string
+	// participation holds how many cycles go through every cookbook and dependency.
+	participation cycle.Participation
+	// redundant contains the dependencies already implied by other dependencies.
+	redundant [][]string
+}
+
+// Option configures optional settings of a whisk handler.
+type Option func(*Handler)
+
+// WithFS makes the handler load roles and cookbooks from fsys, such as a git revision, an
+// archive or an fstest.MapFS, instead of the local filesystem. Cookbook and roles paths are
+// then relative to fsys.
+func WithFS(fsys fs.FS) Option {
+	return func(h *Handler) {
+		h.fsys = fsys
+	}
+}
+
+// WithRoleFilters makes the handler only load the role files matching any of the include
+// patterns, if any, and none of the exclude patterns. See chef.FindRoles.
+func WithRoleFilters(include, exclude []string) Option {
+	return func(h *Handler) {
+		h.include = include
+		h.exclude = exclude
+	}
+}
+
+// WithAllowMissing makes the handler record cookbooks not found in any cookbook path as placeholder
+// vertices, without dependencies, instead of aborting the walk. So partial checkouts can be analyzed.
+func WithAllowMissing() Option {
+	return func(h *Handler) {
+		h.allowMissing = true
+	}
 }
 
 // NewHandler creates a new whisk handler instance.
-func NewHandler(cookbooks []string, rolesPath string) *Handler {
-	return &Handler{
+func NewHandler(cookbooks []string, rolesPath string, opts ...Option) *Handler {
+	h := &Handler{
+		fsys:          chef.LocalFS,
 		cookbookPaths: cookbooks,
 		rolesPath:     rolesPath,
 		graph:         make(map[string][]string),
+		cookbooks:     make(map[string]*chef.Cookbook),
 		rolesIndex:    make(map[string]*chef.Role),
+		missing:       make(map[string]bool),
 	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
 }
 
-// loadRoles preemptively loads and decodes role files. This is
+// loadRoles preemptively loads and decodes role files, recursively. This is
 // so we can do role name lookup since roles names and their file names
 // don't have to match.
 func (h *Handler) loadRoles() error {
-	fn := func(path string, d fs.DirEntry, err error) error {
-		if !strings.HasSuffix(path, ".json") && h.rolesPath != path {
-			return fs.SkipDir
-		}
+	paths, err := chef.FindRoles(h.fsys, h.rolesPath, h.include, h.exclude)
+	if err != nil {
+		return fmt.Errorf("failed loading roles: %w", err)
+	}
 
-		// If there was any error stat()ing path, return it.
+	for _, path := range paths {
+		role, err := chef.NewRoleFS(h.fsys, path)
 		if err != nil {
-			return err
+			return fmt.Errorf("failed loading roles: %w", err)
 		}
 
-		// Do not attempt to index the roles base dir.
-		if path == h.rolesPath {
-			return nil
-		}
-
-		role, err := chef.NewRole(path)
-		if err != nil {
-			return fmt.Errorf("%w", err)
+		if dup, ok := h.rolesIndex[role.Name]; ok {
+			return fmt.Errorf("failed loading roles: role %q is defined in both %q and %q", role.Name, dup.Path, role.Path)
 		}
 
 		h.rolesIndex[role.Name] = role
+	}
 
-		return nil
+	return nil
+}
+
+// Roles returns the names of all the roles found in the roles directory, sorted alphabetically.
+func (h *Handler) Roles() ([]string, error) {
+	if len(h.rolesIndex) == 0 {
+		if err := h.loadRoles(); err != nil {
+			return nil, err
+		}
 	}
 
-	if err := filepath.WalkDir(h.rolesPath, fn); err != nil {
-		return fmt.Errorf("failed loading roles: %w", err)
+	names := make([]string, 0, len(h.rolesIndex))
+	for name := range h.rolesIndex {
+		names = append(names, name)
 	}
+	sort.Strings(names)
 
-	return nil
+	return names, nil
 }
 
 // WalkRole traverses a role's run list using depth-first search and load Chef's
 // dependency graph into memory to work with it.
 func (h *Handler) WalkRole(name string, tree treeprint.Tree) error {
+	h.runList = append(h.runList, fmt.Sprintf("role[%s]", name))
+
+	return h.walkRole(name, tree)
+}
+
+// WalkRunList traverses a run list, such as a node's, using depth-first search, expanding
+// its roles through the roles directory, and loads Chef's dependency graph into memory.
+func (h *Handler) WalkRunList(runList []string, tree treeprint.Tree) error {
+	h.runList = append(h.runList, runList...)
+
+	return h.walkRunList(runList, tree)
+}
+
+// walkRole looks up a role by name and walks its run list.
+func (h *Handler) walkRole(name string, tree treeprint.Tree) error {
 	if len(h.rolesIndex) == 0 {
 		if err := h.loadRoles(); err != nil {
 			return err
@@ -137,26 +175,49 @@ func (h *Handler) WalkRole(name string, tree treeprint.Tree) error {
 		return fmt.Errorf("role %s doesn't exist", name)
 	}
 
-	for _, dep := range role.RunList {
-		switch {
-		case strings.HasPrefix(dep, "role[") && strings.HasSuffix(dep, "]"):
+	h.addWalkedRole(name)
+
+	return h.walkRunList(role.RunList, tree)
+}
+
+// addWalkedRole records a role as walked, unless it already was.
+func (h *Handler) addWalkedRole(name string) {
+	for _, r := range h.walkedRoles {
+		if r == name {
+			return
+		}
+	}
+
+	h.walkedRoles = append(h.walkedRoles, name)
+}
+
+// walkRunList walks every role and recipe in the run list.
+func (h *Handler) walkRunList(runList []string, tree treeprint.Tree) error {
+	if len(h.rolesIndex) == 0 {
+		if err := h.loadRoles(); err != nil {
+			return err
+		}
+	}
+
+	items, err := chef.ParseRunList(runList)
+	if err != nil {
+		return fmt.Errorf("invalid entry in run_list: %w", err)
+	}
+
+	for _, item := range items {
+		switch item.Type {
+		case chef.RoleItem:
 			// making role[foobar] into foobar.json
-			name := dep[5 : len(dep)-1]
-			metaName := fmt.Sprintf("%s.json", name)
+			metaName := fmt.Sprintf("%s.json", item.Name)
 
-			if err := h.WalkRole(name, tree.AddBranch(metaName)); err != nil {
+			if err := h.walkRole(item.Name, tree.AddBranch(metaName)); err != nil {
 				return fmt.Errorf("failed walking run_list: %w", err)
 			}
 
-		case strings.HasPrefix(dep, "recipe[") && strings.HasSuffix(dep, "]"):
-			recipe := dep[7 : len(dep)-1]
-			cookbook := strings.Split(recipe, "::")[0]
-
-			if err := h.walkCookbook(cookbook, tree.AddBranch(cookbook)); err != nil {
+		case chef.RecipeItem:
+			if err := h.walkCookbook(item.Name, tree.AddBranch(item.Name)); err != nil {
 				return err
 			}
-		default:
-			return fmt.Errorf("invalid entry in role's run_list: %q", dep)
 		}
 	}
 
@@ -180,16 +241,19 @@ func (h *Handler) walkCookbook(name string, tree treeprint.Tree) error {
 	// initializes the vertex to not miss it, in case it has no neihgbors.
 	h.graph[name] = []string{}
 
-	// Initialize struct to decode cookbooks metadata into.
-	cookbook := &chef.Cookbook{
-		CookbookPaths: h.cookbookPaths,
-		Name:          name,
-		Deps:          make(map[string]string),
-	}
+	// Initialize cookbook to decode its metadata into.
+	cookbook := chef.NewCookbook(h.fsys, h.cookbookPaths, name)
 
 	if err := cookbook.LoadDeps(); err != nil {
+		if h.allowMissing && errors.Is(err, fs.ErrNotExist) {
+			h.missing[name] = true
+			tree.SetMetaValue("missing")
+			return nil
+		}
+
 		return fmt.Errorf("unable to load %q dependencies: %w", name, err)
 	}
+	h.cookbooks[name] = cookbook
 
 	for _, dep := range sortKeys(cookbook.Deps) {
 		h.graph[name] = append(h.graph[name], dep)
@@ -205,6 +269,18 @@ func (h *Handler) walkCookbook(name string, tree treeprint.Tree) error {
 	return nil
 }
 
+// DependencyLocation returns the path of the metadata file where cookbook declares its dependency
+// on dep, along with its line number. The line is 0 when unknown, and the path is empty when the
+// cookbook wasn't walked or is missing.
+func (h *Handler) DependencyLocation(cookbook, dep string) (string, int) {
+	c, ok := h.cookbooks[cookbook]
+	if !ok {
+		return "", 0
+	}
+
+	return c.Metadata, c.Lines[dep]
+}
+
 // FindSCCs finds strongly connected components in the dependency graph.
 func (h *Handler) FindSCCs() error {
 	t := scc.NewTarjan(h.graph)
@@ -233,10 +309,58 @@ func (h *Handler) FindCycles() error {
 		return fmt.Errorf("failed finding cycles: %w", err)
 	}
 	h.cycles = cycles
+	h.participation = cycle.Count(h.graph, cycles)
+
+	return nil
+}
+
+// FindRedundantDeps finds the dependencies that can be removed without changing which
+// cookbooks are reachable from each other, according to the transitive reduction of the graph.
+// Once found, they're listed in the JSON document and left out of the DOT, SVG, Mermaid and
+// PlantUML diagrams, which draw the transitive reduction instead.
+func (h *Handler) FindRedundantDeps() error {
+	r := reduction.NewReducer(h.graph)
+
+	redundant, err := r.Find()
+	if err != nil {
+		return fmt.Errorf("failed finding redundant dependencies: %w", err)
+	}
+	h.redundant = redundant
 
 	return nil
 }
 
+// Stats computes graph metrics for every cookbook walked so far, ranking first
+// the cookbooks participating in the most cycles. FindCycles must be called beforehand.
+func (h *Handler) Stats() ([]metrics.Vertex, error) {
+	c := metrics.NewCalculator(h.graph, h.cycles)
+
+	stats, err := c.Compute()
+	if err != nil {
+		return nil, fmt.Errorf("failed computing graph metrics: %w", err)
+	}
+
+	return stats, nil
+}
+
+// CookbookParticipation is the number of distinct cycles going through a cookbook.
+type CookbookParticipation struct {
+	// Cookbook is the cookbook's name.
+	Cookbook string `json:"cookbook"`
+	// Cycles is the number of cycles going through the cookbook.
+	Cycles int `json:"cycles"`
+}
+
+// EdgeParticipation is the number of distinct cycles going through a cookbook's dependency.
+type EdgeParticipation struct {
+	// Cookbook is the name of the cookbook declaring the dependency.
+	Cookbook string `json:"cookbook"`
+	// Dependency is the name of the cookbook depended upon.
+	Dependency string `json:"dependency"`
+	// Cycles is the number of cycles going through the dependency.
+	Cycles int `json:"cycles"`
+}
+
 // Result defines the struct to return back to callers using the different output formats.
 type Result struct {
 	// G is the digraph of the role
@@ -245,17 +369,112 @@ type Result struct {
 	Sccs [][]string `json:"sccs"`
 	// Cycles contains all the distinct cycles found in the digraph.
 	Cycles [][]string `json:"cycles"`
+	// CookbookCycles ranks every cookbook by the number of cycles going through it.
+	CookbookCycles []CookbookParticipation `json:"cookbook_cycles"`
+	// EdgeCycles ranks every dependency by the number of cycles going through it.
+	EdgeCycles []EdgeParticipation `json:"edge_cycles"`
+	// Redundant are the {cookbook, dependency} pairs implied by other dependencies.
+	Redundant [][]string `json:"redundant,omitempty"`
+	// Violations are the role validation rules not met by the roles walked.
+	Violations []Violation `json:"violations,omitempty"`
+	// Missing are the cookbooks not found in any cookbook path, walked as placeholder vertices.
+	Missing []MissingCookbook `json:"missing,omitempty"`
+}
+
+// MissingCookbook is a cookbook whose metadata wasn't found.
+type MissingCookbook struct {
+	// Cookbook is the missing cookbook's name.
+	Cookbook string `json:"cookbook"`
+	// RequiredBy lists the cookbooks depending on the missing cookbook, if any.
+	RequiredBy []string `json:"required_by"`
 }
 
 // Result returns the dependency analysis results.
 func (h *Handler) Result() Result {
 	return Result{
-		G:      h.graph,
-		Sccs:   h.sccs,
-		Cycles: h.cycles,
+		G:              h.graph,
+		Sccs:           h.sccs,
+		Cycles:         h.cycles,
+		CookbookCycles: h.cookbookParticipation(),
+		EdgeCycles:     h.edgeParticipation(),
+		Redundant:      h.redundant,
+		Violations:     h.violations,
+		Missing:        h.missingCookbooks(),
 	}
 }
 
+// missingCookbooks returns the missing cookbooks sorted by name, along with the cookbooks depending on them.
+func (h *Handler) missingCookbooks() []MissingCookbook {
+	if len(h.missing) == 0 {
+		return nil
+	}
+
+	missing := make([]MissingCookbook, 0, len(h.missing))
+	for name := range h.missing {
+		requiredBy := []string{}
+		for v, deps := range h.graph {
+			for _, dep := range deps {
+				if dep == name {
+					requiredBy = append(requiredBy, v)
+					break
+				}
+			}
+		}
+		sort.Strings(requiredBy)
+
+		missing = append(missing, MissingCookbook{Cookbook: name, RequiredBy: requiredBy})
+	}
+
+	sort.Slice(missing, func(i, j int) bool {
+		return missing[i].Cookbook < missing[j].Cookbook
+	})
+
+	return missing
+}
+
+// cookbookParticipation returns the cookbooks sorted by the number of cycles going
+// through them, in descending order, and then by name.
+func (h *Handler) cookbookParticipation() []CookbookParticipation {
+	cookbooks := make([]CookbookParticipation, 0, len(h.participation.Vertices))
+	for name, n := range h.participation.Vertices {
+		cookbooks = append(cookbooks, CookbookParticipation{Cookbook: name, Cycles: n})
+	}
+
+	sort.Slice(cookbooks, func(i, j int) bool {
+		if cookbooks[i].Cycles != cookbooks[j].Cycles {
+			return cookbooks[i].Cycles > cookbooks[j].Cycles
+		}
+		return cookbooks[i].Cookbook < cookbooks[j].Cookbook
+	})
+
+	return cookbooks
+}
+
+// edgeParticipation returns the dependencies sorted by the number of cycles going
+// through them, in descending order, and then by cookbook and dependency names.
+func (h *Handler) edgeParticipation() []EdgeParticipation {
+	edges := []EdgeParticipation{}
+	for from, deps := range h.participation.Edges {
+		for to, n := range deps {
+			edges = append(edges, EdgeParticipation{Cookbook: from, Dependency: to, Cycles: n})
+		}
+	}
+
+	sort.Slice(edges, func(i, j int) bool {
+		a, b := edges[i], edges[j]
+		switch {
+		case a.Cycles != b.Cycles:
+			return a.Cycles > b.Cycles
+		case a.Cookbook != b.Cookbook:
+			return a.Cookbook < b.Cookbook
+		default:
+			return a.Dependency < b.Dependency
+		}
+	})
+
+	return edges
+}
+
 // ASCII encodes the dependency graph using unicode ¯\_(ツ)_/¯
 func (h *Handler) ASCII(tree treeprint.Tree, w io.Writer) {
 	fmt.Fprintf(w, "%s\n", tree.String())
@@ -266,8 +485,8 @@ func (h *Handler) ASCII(tree treeprint.Tree, w io.Writer) {
 		fmt.Fprintf(w, "None! 🍻 🎉 \n\n")
 	}
 
+	// Components are numbered by their id, starting at 0 like in the other output formats and --focus.
 	for i, c := range h.sccs {
-		i++
 		scc := strings.Join(c, ", ")
 		fmt.Fprintf(w, "%d. %s\n", i, scc)
 	}
@@ -283,28 +502,42 @@ func (h *Handler) ASCII(tree treeprint.Tree, w io.Writer) {
 		scc := strings.Join(c, ", ")
 		fmt.Fprintf(w, "%d. %s\n", i, scc)
 	}
-}
 
-// dotOutput encodes the dependency graph to graphviz's dot format.
-func (h *Handler) DOT(w io.Writer) error {
-	funcMap := template.FuncMap{
-		// replace is used to make node names valid Dot identifiers.
-		"replace": strings.ReplaceAll,
+	if missing := h.missingCookbooks(); len(missing) > 0 {
+		fmt.Fprintf(w, "\n\n❓ Missing cookbooks: %d\n\n", len(missing))
+		for i, m := range missing {
+			if len(m.RequiredBy) == 0 {
+				fmt.Fprintf(w, "%d. %s (required by the run list)\n", i+1, m.Cookbook)
+				continue
+			}
+			fmt.Fprintf(w, "%d. %s (required by %s)\n", i+1, m.Cookbook, strings.Join(m.RequiredBy, ", "))
+		}
 	}
 
-	tpl, err := template.New("graph").Funcs(funcMap).Parse(dotTpl)
-	if err != nil {
-		return fmt.Errorf("failed parsing dot template: %w", err)
+	if violations := h.reportedViolations(); len(violations) > 0 {
+		fmt.Fprintf(w, "\n\n📋 Role validation violations: %d\n\n", len(violations))
+		for i, v := range violations {
+			fmt.Fprintf(w, "%d. %s\n", i+1, v)
+		}
 	}
 
-	if err := tpl.Execute(w, h.Result()); err != nil {
-		return fmt.Errorf("failed executing dot template: %w", err)
+	if totalCycles == 0 {
+		return
 	}
 
-	return nil
-}
+	fmt.Fprintf(w, "\n\n🔥 Dependencies ranked by cycles going through them:\n\n")
+	for i, e := range h.edgeParticipation() {
+		if e.Cycles == 0 {
+			break
+		}
+		fmt.Fprintf(w, "%d. %s -> %s: %d\n", i+1, e.Cookbook, e.Dependency, e.Cycles)
+	}
 
-// jsonOutput encodes the dependency graph to JSON.
-func (h *Handler) JSON(w io.Writer) error {
-	return json.NewEncoder(w).Encode(h.Result())
+	fmt.Fprintf(w, "\n\n🔥 Cookbooks ranked by cycles going through them:\n\n")
+	for i, c := range h.cookbookParticipation() {
+		if c.Cycles == 0 {
+			break
+		}
+		fmt.Fprintf(w, "%d. %s: %d\n", i+1, c.Cookbook, c.Cycles)
+	}
 }
@@ -0,0 +1,101 @@
+package whisk
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/xlab/treeprint"
+)
+
+func TestHandlerWalkRole(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+
+	fsys := fstest.MapFS{
+		"roles/base.json":             {Data: []byte(`{"name": "base", "run_list": ["recipe[apt]"]}`)},
+		"roles/web.json":              {Data: []byte(`{"name": "web", "run_list": ["role[base]", "recipe[nginx::default]"]}`)},
+		"cookbooks/apt/metadata.rb":   {Data: []byte("name 'apt'\n")},
+		"cookbooks/nginx/metadata.rb": {Data: []byte("name 'nginx'\ndepends 'apt'\ndepends 'ssl'\n")},
+		"cookbooks/ssl/metadata.json": {Data: []byte(`{"name": "ssl", "dependencies": {"nginx": ""}}`)},
+	}
+
+	h := NewHandler([]string{"cookbooks"}, "roles", WithFS(fsys))
+	c.Assert(h.WalkRole("web", treeprint.New()), qt.IsNil)
+	c.Assert(h.FindSCCs(), qt.IsNil)
+	c.Assert(h.FindCycles(), qt.IsNil)
+
+	r := h.Result()
+	c.Assert(r.G, qt.DeepEquals, map[string][]string{
+		"apt":   {},
+		"nginx": {"apt", "ssl"},
+		"ssl":   {"nginx"},
+	})
+	c.Assert(r.Sccs, qt.DeepEquals, [][]string{{"ssl", "nginx"}})
+	c.Assert(r.Cycles, qt.DeepEquals, [][]string{{"nginx", "ssl", "nginx"}})
+}
+
+func TestHandlerDuplicateRoles(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+
+	fsys := fstest.MapFS{
+		"roles/web.json":      {Data: []byte(`{"name": "web", "run_list": []}`)},
+		"roles/prod/web.json": {Data: []byte(`{"name": "web", "run_list": []}`)},
+		"roles/prod/db.json":  {Data: []byte(`{"name": "db", "run_list": []}`)},
+	}
+
+	h := NewHandler(nil, "roles", WithFS(fsys))
+	_, err := h.Roles()
+	c.Assert(err, qt.ErrorMatches, `.*role "web" is defined in both "roles/prod/web.json" and "roles/web.json"`)
+
+	h = NewHandler(nil, "roles", WithFS(fsys), WithRoleFilters(nil, []string{"prod/web.json"}))
+	roles, err := h.Roles()
+	c.Assert(err, qt.IsNil)
+	c.Assert(roles, qt.DeepEquals, []string{"db", "web"})
+}
+
+func TestHandlerAllowMissing(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+
+	fsys := fstest.MapFS{
+		"roles/web.json":              {Data: []byte(`{"name": "web", "run_list": ["recipe[nginx]", "recipe[php]"]}`)},
+		"cookbooks/nginx/metadata.rb": {Data: []byte("name 'nginx'\ndepends 'ssl'\n")},
+	}
+
+	h := NewHandler([]string{"cookbooks"}, "roles", WithFS(fsys))
+	c.Assert(h.WalkRole("web", treeprint.New()), qt.ErrorMatches, `unable to load "ssl" dependencies: .*`)
+
+	h = NewHandler([]string{"cookbooks"}, "roles", WithFS(fsys), WithAllowMissing())
+	c.Assert(h.WalkRole("web", treeprint.New()), qt.IsNil)
+
+	r := h.Result()
+	c.Assert(r.G, qt.DeepEquals, map[string][]string{
+		"nginx": {"ssl"},
+		"ssl":   {},
+		"php":   {},
+	})
+	c.Assert(r.Missing, qt.DeepEquals, []MissingCookbook{
+		{Cookbook: "php", RequiredBy: []string{}},
+		{Cookbook: "ssl", RequiredBy: []string{"nginx"}},
+	})
+}
+
+func TestHandlerASCIISCCIDs(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+
+	h := documentHandler(c)
+
+	var b bytes.Buffer
+	h.ASCII(treeprint.New(), &b)
+
+	// Components are numbered like in the JSON document, so they can be passed to --focus.
+	doc := h.Document()
+	c.Assert(doc.SCCs, qt.HasLen, 1)
+	c.Assert(b.String(), qt.Contains, fmt.Sprintf("\n%d. %s\n", doc.SCCs[0].ID, strings.Join(h.sccs[0], ", ")))
+}
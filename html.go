@@ -0,0 +1,88 @@
+package whisk
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/xlab/treeprint"
+)
+
+// reportTpl is the HTML report's template. Its styles and scripts are inlined so the report is a
+// single file that works offline, such as when downloaded from CI artifacts.
+//
+//go:embed templates/report.html
+var reportTpl string
+
+// htmlNode is a cookbook as drawn by the HTML report.
+type htmlNode struct {
+	Name    string `json:"name"`
+	SCC     int    `json:"scc"`
+	Missing bool   `json:"missing"`
+}
+
+// htmlEdge is a dependency as drawn by the HTML report.
+type htmlEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Cycles int    `json:"cycles"`
+}
+
+// htmlData is the graph the HTML report's script draws.
+type htmlData struct {
+	Nodes  []htmlNode `json:"nodes"`
+	Edges  []htmlEdge `json:"edges"`
+	SCCs   int        `json:"sccs"`
+	Cycles [][]string `json:"cycles"`
+}
+
+// htmlReport holds the values the HTML report's template is executed with.
+type htmlReport struct {
+	Title string
+	Tree  string
+	Data  htmlData
+}
+
+// HTML encodes the dependency graph as a self-contained HTML page to explore it: a zoomable
+// force-directed graph coloring cookbooks by strongly connected component, the list of cycles
+// highlighting their path when clicked, a search by cookbook, and the dependency tree.
+func (h *Handler) HTML(tree treeprint.Tree, w io.Writer) error {
+	tpl, err := template.New("report").Parse(reportTpl)
+	if err != nil {
+		return fmt.Errorf("failed parsing HTML template: %w", err)
+	}
+
+	nodes, edges := h.exportGraph()
+
+	data := htmlData{
+		Nodes:  make([]htmlNode, 0, len(nodes)),
+		Edges:  make([]htmlEdge, 0, len(edges)),
+		SCCs:   len(h.sccs),
+		Cycles: h.cycles,
+	}
+	if data.Cycles == nil {
+		data.Cycles = [][]string{}
+	}
+
+	for _, n := range nodes {
+		data.Nodes = append(data.Nodes, htmlNode{Name: n.name, SCC: n.scc, Missing: n.missing})
+	}
+
+	for _, e := range edges {
+		data.Edges = append(data.Edges, htmlEdge{Source: e.source, Target: e.target, Cycles: e.cycles})
+	}
+
+	report := htmlReport{
+		Title: strings.Join(h.runList, ", "),
+		Tree:  tree.String(),
+		Data:  data,
+	}
+
+	if err := tpl.Execute(w, report); err != nil {
+		return fmt.Errorf("failed executing HTML template: %w", err)
+	}
+
+	return nil
+}
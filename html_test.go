@@ -0,0 +1,78 @@
+package whisk
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/xlab/treeprint"
+)
+
+func TestHandlerHTML(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"roles/web.json":              {Data: []byte(`{"name": "web", "run_list": ["recipe[app]"]}`)},
+		"cookbooks/app/metadata.rb":   {Data: []byte("name 'app'\ndepends 'nginx'\ndepends 'vault'\n")},
+		"cookbooks/nginx/metadata.rb": {Data: []byte("name 'nginx'\ndepends 'ssl'\n")},
+		"cookbooks/ssl/metadata.rb":   {Data: []byte("name 'ssl'\ndepends 'nginx'\n")},
+	}
+
+	tree := treeprint.New()
+	h := NewHandler([]string{"cookbooks"}, "roles", WithFS(fsys), WithAllowMissing())
+	qt.Assert(t, h.WalkRole("web", tree), qt.IsNil)
+	qt.Assert(t, h.FindSCCs(), qt.IsNil)
+	qt.Assert(t, h.FindCycles(), qt.IsNil)
+
+	var b bytes.Buffer
+	qt.Assert(t, h.HTML(tree, &b), qt.IsNil)
+	out := b.String()
+
+	t.Run("it should embed the graph data", func(t *testing.T) {
+		t.Parallel()
+		c := qt.New(t)
+
+		start := strings.Index(out, "var data = ")
+		c.Assert(start, qt.Not(qt.Equals), -1)
+		raw := out[start+len("var data = "):]
+		raw = raw[:strings.Index(raw, ";\n")]
+
+		var data htmlData
+		c.Assert(json.Unmarshal([]byte(raw), &data), qt.IsNil)
+		c.Assert(data, qt.DeepEquals, htmlData{
+			Nodes: []htmlNode{
+				{Name: "app", SCC: -1},
+				{Name: "nginx", SCC: 0},
+				{Name: "ssl", SCC: 0},
+				{Name: "vault", SCC: -1, Missing: true},
+			},
+			Edges: []htmlEdge{
+				{Source: "app", Target: "nginx"},
+				{Source: "app", Target: "vault"},
+				{Source: "nginx", Target: "ssl", Cycles: 1},
+				{Source: "ssl", Target: "nginx", Cycles: 1},
+			},
+			SCCs:   1,
+			Cycles: [][]string{{"nginx", "ssl", "nginx"}},
+		})
+	})
+
+	t.Run("it should include the dependency tree", func(t *testing.T) {
+		t.Parallel()
+		c := qt.New(t)
+
+		c.Assert(out, qt.Contains, "<pre>"+strings.ReplaceAll(tree.String(), "'", "&#39;")+"</pre>")
+	})
+
+	t.Run("it should not load external resources", func(t *testing.T) {
+		t.Parallel()
+		c := qt.New(t)
+
+		for _, ref := range []string{"src=", "href=", "http://", "https://", "@import"} {
+			c.Assert(out, qt.Not(qt.Contains), ref)
+		}
+	})
+}
@@ -0,0 +1,120 @@
+// Package memfs implements the open files and directories of read-only filesystems whose contents
+// are held in memory, such as the ones serving cookbooks from artifacts, git commits or a Chef Server.
+package memfs
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"time"
+)
+
+// Info describes a file or directory, implementing both fs.FileInfo and fs.DirEntry.
+type Info struct {
+	// name is the base name of the file or directory.
+	name string
+	// mode holds the permission bits, along with fs.ModeDir for directories.
+	mode fs.FileMode
+	// size is the file's length in bytes.
+	size int64
+}
+
+// NewInfo returns the description of a file or directory named name.
+func NewInfo(name string, mode fs.FileMode, size int64) *Info {
+	return &Info{name: name, mode: mode, size: size}
+}
+
+// NewDirInfo returns the description of a read-only directory named name.
+func NewDirInfo(name string) *Info {
+	return NewInfo(name, fs.ModeDir|0o555, 0)
+}
+
+// Name returns the base name of the file or directory.
+func (i *Info) Name() string { return i.name }
+
+// Size returns the length in bytes of files, or zero for directories.
+func (i *Info) Size() int64 { return i.size }
+
+// Mode returns the file mode bits.
+func (i *Info) Mode() fs.FileMode { return i.mode }
+
+// ModTime returns the zero time, since modification times are not kept.
+func (i *Info) ModTime() time.Time { return time.Time{} }
+
+// IsDir reports whether it describes a directory.
+func (i *Info) IsDir() bool { return i.mode.IsDir() }
+
+// Sys returns nil.
+func (i *Info) Sys() any { return nil }
+
+// Type returns the type bits of the file mode.
+func (i *Info) Type() fs.FileMode { return i.mode.Type() }
+
+// Info returns the info itself, as it also implements fs.FileInfo.
+func (i *Info) Info() (fs.FileInfo, error) { return i, nil }
+
+// File is an open file whose content is in memory.
+type File struct {
+	*bytes.Reader
+	// info describes the file.
+	info *Info
+}
+
+// NewFile returns an open file named after the base of p, with the given mode, holding data.
+func NewFile(p string, mode fs.FileMode, data []byte) *File {
+	return &File{Reader: bytes.NewReader(data), info: NewInfo(path.Base(p), mode, int64(len(data)))}
+}
+
+// Stat returns the file's information.
+func (f *File) Stat() (fs.FileInfo, error) { return f.info, nil }
+
+// Close is a no-op, since the file's content is in memory.
+func (f *File) Close() error { return nil }
+
+// Dir is an open directory.
+type Dir struct {
+	// path is the directory's path, as opened.
+	path string
+	// info describes the directory.
+	info *Info
+	// entries are the directory's entries, sorted by name.
+	entries []fs.DirEntry
+	// offset is the number of entries already returned by ReadDir.
+	offset int
+}
+
+// NewDir returns an open directory named after the base of p, holding entries sorted by name.
+func NewDir(p string, entries []fs.DirEntry) *Dir {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	return &Dir{path: p, info: NewDirInfo(path.Base(p)), entries: entries}
+}
+
+// Stat returns the directory's information.
+func (d *Dir) Stat() (fs.FileInfo, error) { return d.info, nil }
+
+// Close is a no-op.
+func (d *Dir) Close() error { return nil }
+
+// Read fails, as directories can't be read.
+func (d *Dir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.path, Err: errors.New("is a directory")}
+}
+
+// ReadDir returns up to n of the directory's remaining entries, or all of them if n <= 0.
+func (d *Dir) ReadDir(n int) ([]fs.DirEntry, error) {
+	entries := d.entries[d.offset:]
+	if n > 0 && len(entries) == 0 {
+		return nil, io.EOF
+	}
+
+	if n > 0 && len(entries) > n {
+		entries = entries[:n]
+	}
+	d.offset += len(entries)
+
+	return entries, nil
+}
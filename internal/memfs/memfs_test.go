@@ -0,0 +1,74 @@
+package memfs
+
+import (
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	qt "github.com/frankban/quicktest"
+)
+
+// testFS serves its files, keyed by path, from the root directory.
+type testFS map[string]string
+
+// Open opens the named file or directory.
+func (t testFS) Open(name string) (fs.File, error) {
+	if name == "." {
+		entries := make([]fs.DirEntry, 0, len(t))
+		for n, data := range t {
+			entries = append(entries, NewInfo(n, 0o444, int64(len(data))))
+		}
+
+		return NewDir(name, entries), nil
+	}
+
+	data, ok := t[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return NewFile(name, 0o444, []byte(data)), nil
+}
+
+func TestFS(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+
+	fsys := testFS{"metadata.rb": "name 'apt'\n", "README.md": "# apt\n", "CHANGELOG.md": ""}
+	c.Assert(fstest.TestFS(fsys, "metadata.rb", "README.md", "CHANGELOG.md"), qt.IsNil)
+}
+
+func TestDirReadDir(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+
+	d := NewDir("cookbooks", []fs.DirEntry{NewDirInfo("users"), NewDirInfo("apt"), NewDirInfo("nginx")})
+
+	info, err := d.Stat()
+	c.Assert(err, qt.IsNil)
+	c.Assert(info.Name(), qt.Equals, "cookbooks")
+	c.Assert(info.IsDir(), qt.IsTrue)
+
+	_, err = d.Read(nil)
+	c.Assert(err, qt.ErrorMatches, "read cookbooks: is a directory")
+
+	var names []string
+	for {
+		entries, err := d.ReadDir(2)
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, qt.IsNil)
+
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+	}
+	c.Assert(strings.Join(names, ","), qt.Equals, "apt,nginx,users")
+
+	entries, err := d.ReadDir(0)
+	c.Assert(err, qt.IsNil)
+	c.Assert(entries, qt.HasLen, 0)
+}
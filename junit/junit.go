@@ -0,0 +1,91 @@
+// Package junit implements the JUnit XML report format, as ingested by most CI systems.
+package junit
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+// TestSuites is the report's root element.
+type TestSuites struct {
+	XMLName  xml.Name    `xml:"testsuites"`
+	Name     string      `xml:"name,attr,omitempty"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+	Time     string      `xml:"time,attr"`
+	Suites   []TestSuite `xml:"testsuite"`
+}
+
+// TestSuite is a group of test cases.
+type TestSuite struct {
+	Name      string     `xml:"name,attr"`
+	Tests     int        `xml:"tests,attr"`
+	Failures  int        `xml:"failures,attr"`
+	Errors    int        `xml:"errors,attr"`
+	Time      string     `xml:"time,attr"`
+	TestCases []TestCase `xml:"testcase"`
+}
+
+// TestCase is a single test, which either passed, failed or errored.
+type TestCase struct {
+	Name      string   `xml:"name,attr"`
+	ClassName string   `xml:"classname,attr"`
+	Time      string   `xml:"time,attr"`
+	Failure   *Problem `xml:"failure,omitempty"`
+	Error     *Problem `xml:"error,omitempty"`
+}
+
+// Problem is a test case failure or error.
+type Problem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",cdata"`
+}
+
+// Seconds formats a duration in seconds, as JUnit time attributes expect.
+func Seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// NewSuite creates a test suite out of its test cases, computing its counters.
+func NewSuite(name string, elapsed time.Duration, cases []TestCase) TestSuite {
+	s := TestSuite{Name: name, Tests: len(cases), Time: Seconds(elapsed), TestCases: cases}
+	for _, c := range cases {
+		if c.Failure != nil {
+			s.Failures++
+		}
+
+		if c.Error != nil {
+			s.Errors++
+		}
+	}
+
+	return s
+}
+
+// Encode writes a report with the given test suites, computing its counters.
+func Encode(w io.Writer, name string, elapsed time.Duration, suites ...TestSuite) error {
+	report := TestSuites{Name: name, Time: Seconds(elapsed), Suites: suites}
+	for _, s := range suites {
+		report.Tests += s.Tests
+		report.Failures += s.Failures
+		report.Errors += s.Errors
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+
+	return err
+}
@@ -0,0 +1,41 @@
+package junit
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestEncode(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+
+	suite := NewSuite("lint", 1500*time.Millisecond, []TestCase{
+		{Name: "base", ClassName: "roles/base.json", Time: Seconds(250 * time.Millisecond)},
+		{
+			Name:      "web",
+			ClassName: "roles/web.json",
+			Time:      Seconds(time.Second),
+			Failure:   &Problem{Message: "1 linting error", Type: "max-cycles", Text: "web: 1 cycles found. Max threshold: 0"},
+		},
+		{Name: "db", ClassName: "roles/db.json", Time: Seconds(0), Error: &Problem{Message: "db: role base doesn't exist"}},
+	})
+
+	var buf bytes.Buffer
+	c.Assert(Encode(&buf, "whisk", 2*time.Second, suite), qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="whisk" tests="3" failures="1" errors="1" time="2.000">
+  <testsuite name="lint" tests="3" failures="1" errors="1" time="1.500">
+    <testcase name="base" classname="roles/base.json" time="0.250"></testcase>
+    <testcase name="web" classname="roles/web.json" time="1.000">
+      <failure message="1 linting error" type="max-cycles"><![CDATA[web: 1 cycles found. Max threshold: 0]]></failure>
+    </testcase>
+    <testcase name="db" classname="roles/db.json" time="0.000">
+      <error message="db: role base doesn&#39;t exist"></error>
+    </testcase>
+  </testsuite>
+</testsuites>
+`)
+}
@@ -0,0 +1,181 @@
+package whisk
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"slack/whisk/chef"
+)
+
+// topLevelSource is the source of run list entries not coming from any role,
+// such as those in a node's own run list.
+const topLevelSource = "run_list"
+
+// RunListRecipe is a recipe of the expanded run list.
+type RunListRecipe struct {
+	// Recipe is the fully qualified recipe name, as in cookbook::recipe.
+	Recipe string `json:"recipe"`
+	// Cookbook is the cookbook the recipe belongs to.
+	Cookbook string `json:"cookbook"`
+	// Version is the cookbook version the recipe is pinned to, if any.
+	Version string `json:"version,omitempty"`
+	// Sources lists where the recipe appears: either run_list or the role[...] including it.
+	Sources []string `json:"sources"`
+}
+
+// OrderViolation is a recipe converging before a recipe of a cookbook it depends on.
+type OrderViolation struct {
+	// Recipe is the recipe running first.
+	Recipe string `json:"recipe"`
+	// Dependency is the recipe running later, whose cookbook the former's depends on.
+	Dependency string `json:"dependency"`
+}
+
+// RunListExpansion is the run list as Chef converges it, once roles are expanded.
+type RunListExpansion struct {
+	// Recipes is the de-duplicated list of recipes, in convergence order.
+	Recipes []RunListRecipe `json:"recipes"`
+	// Violations lists recipes running before recipes of cookbooks they depend on.
+	Violations []OrderViolation `json:"violations"`
+}
+
+// ExpandRunList expands the run list walked the same way Chef does: roles are expanded
+// depth-first, in place, and only the first time they are applied, and recipes keep the position
+// of their first appearance. It must be called after WalkRole or WalkRunList, since order violations
+// are checked against the dependency graph loaded.
+func (h *Handler) ExpandRunList() (RunListExpansion, error) {
+	var exp RunListExpansion
+
+	applied := make(map[string]bool)
+	index := make(map[string]int)
+	if err := h.expand(h.runList, topLevelSource, applied, index, &exp); err != nil {
+		return exp, err
+	}
+
+	reachable := make(map[string]map[string]bool)
+	for i, a := range exp.Recipes {
+		if _, ok := reachable[a.Cookbook]; !ok {
+			reachable[a.Cookbook] = h.reachable(a.Cookbook)
+		}
+
+		for _, b := range exp.Recipes[i+1:] {
+			if b.Cookbook != a.Cookbook && reachable[a.Cookbook][b.Cookbook] {
+				exp.Violations = append(exp.Violations, OrderViolation{Recipe: a.Recipe, Dependency: b.Recipe})
+			}
+		}
+	}
+
+	return exp, nil
+}
+
+// expand appends the recipes of the run list to exp, recursively expanding roles not applied yet.
+func (h *Handler) expand(runList []string, source string, applied map[string]bool, index map[string]int, exp *RunListExpansion) error {
+	if len(h.rolesIndex) == 0 {
+		if err := h.loadRoles(); err != nil {
+			return err
+		}
+	}
+
+	items, err := chef.ParseRunList(runList)
+	if err != nil {
+		return fmt.Errorf("invalid entry in run_list: %w", err)
+	}
+
+	for _, item := range items {
+		switch item.Type {
+		case chef.RoleItem:
+			if applied[item.Name] {
+				continue
+			}
+			applied[item.Name] = true
+
+			role, ok := h.rolesIndex[item.Name]
+			if !ok {
+				return fmt.Errorf("role %s doesn't exist", item.Name)
+			}
+
+			if err := h.expand(role.RunList, item.String(), applied, index, exp); err != nil {
+				return err
+			}
+
+		case chef.RecipeItem:
+			recipe := item.QualifiedRecipe()
+			if i, ok := index[recipe]; ok {
+				exp.Recipes[i].Sources = append(exp.Recipes[i].Sources, source)
+				continue
+			}
+
+			index[recipe] = len(exp.Recipes)
+			exp.Recipes = append(exp.Recipes, RunListRecipe{
+				Recipe:   recipe,
+				Cookbook: item.Name,
+				Version:  item.Version,
+				Sources:  []string{source},
+			})
+		}
+	}
+
+	return nil
+}
+
+// reachable returns the set of cookbooks the given cookbook depends on, directly or transitively.
+func (h *Handler) reachable(cookbook string) map[string]bool {
+	seen := make(map[string]bool)
+	stack := []string{cookbook}
+	for len(stack) > 0 {
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		for _, dep := range h.graph[v] {
+			if !seen[dep] {
+				seen[dep] = true
+				stack = append(stack, dep)
+			}
+		}
+	}
+
+	return seen
+}
+
+// RunList writes the expanded run list, flagging recipes included more than once and
+// recipes running before recipes of cookbooks they depend on.
+func (h *Handler) RunList(w io.Writer) error {
+	exp, err := h.ExpandRunList()
+	if err != nil {
+		return fmt.Errorf("failed expanding run list: %w", err)
+	}
+
+	fmt.Fprintf(w, "📜 Expanded run list: %d\n\n", len(exp.Recipes))
+	var duplicates []RunListRecipe
+	for i, r := range exp.Recipes {
+		recipe := r.Recipe
+		if r.Version != "" {
+			recipe = fmt.Sprintf("%s@%s", recipe, r.Version)
+		}
+		fmt.Fprintf(w, "%d. %s (%s)\n", i+1, recipe, r.Sources[0])
+		if len(r.Sources) > 1 {
+			duplicates = append(duplicates, r)
+		}
+	}
+
+	fmt.Fprintf(w, "\n\n🔁 Recipes included more than once: %d\n\n", len(duplicates))
+	if len(duplicates) == 0 {
+		fmt.Fprintf(w, "None! 🍻 🎉 \n\n")
+	}
+
+	for i, r := range duplicates {
+		fmt.Fprintf(w, "%d. %s: %s\n", i+1, r.Recipe, strings.Join(r.Sources, ", "))
+	}
+
+	fmt.Fprintf(w, "\n\n⚠️  Recipes running before their dependencies: %d\n\n", len(exp.Violations))
+	if len(exp.Violations) == 0 {
+		fmt.Fprintf(w, "None! 🍻 🎉 \n\n")
+	}
+
+	for i, v := range exp.Violations {
+		fmt.Fprintf(w, "%d. %s runs before %s\n", i+1, v.Recipe, v.Dependency)
+	}
+
+	return nil
+}
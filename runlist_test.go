@@ -0,0 +1,70 @@
+package whisk
+
+import (
+	"testing"
+	"testing/fstest"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/xlab/treeprint"
+)
+
+func TestHandlerExpandRunList(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"roles/base.json":             {Data: []byte(`{"name": "base", "run_list": ["recipe[apt]", "recipe[ssl::certs]"]}`)},
+		"roles/web.json":              {Data: []byte(`{"name": "web", "run_list": ["role[base]", "recipe[nginx]", "recipe[apt::default]"]}`)},
+		"roles/app.json":              {Data: []byte(`{"name": "app", "run_list": ["recipe[nginx]", "recipe[ssl]"]}`)},
+		"cookbooks/apt/metadata.rb":   {Data: []byte("name 'apt'\n")},
+		"cookbooks/nginx/metadata.rb": {Data: []byte("name 'nginx'\ndepends 'apt'\ndepends 'ssl'\n")},
+		"cookbooks/ssl/metadata.rb":   {Data: []byte("name 'ssl'\n")},
+	}
+
+	tests := []struct {
+		name     string
+		runList  []string
+		expected RunListExpansion
+	}{
+		{
+			"it should expand roles in place and keep the first appearance of repeated recipes",
+			[]string{"role[web]", "role[base]"},
+			RunListExpansion{
+				Recipes: []RunListRecipe{
+					{Recipe: "apt::default", Cookbook: "apt", Sources: []string{"role[base]", "role[web]"}},
+					{Recipe: "ssl::certs", Cookbook: "ssl", Sources: []string{"role[base]"}},
+					{Recipe: "nginx::default", Cookbook: "nginx", Sources: []string{"role[web]"}},
+				},
+			},
+		},
+		{
+			"it should flag recipes running before recipes of cookbooks they depend on",
+			[]string{"role[app]", "apt@1.0.0"},
+			RunListExpansion{
+				Recipes: []RunListRecipe{
+					{Recipe: "nginx::default", Cookbook: "nginx", Sources: []string{"role[app]"}},
+					{Recipe: "ssl::default", Cookbook: "ssl", Sources: []string{"role[app]"}},
+					{Recipe: "apt::default", Cookbook: "apt", Version: "1.0.0", Sources: []string{"run_list"}},
+				},
+				Violations: []OrderViolation{
+					{Recipe: "nginx::default", Dependency: "ssl::default"},
+					{Recipe: "nginx::default", Dependency: "apt::default"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := qt.New(t)
+
+			h := NewHandler([]string{"cookbooks"}, "roles", WithFS(fsys))
+			c.Assert(h.WalkRunList(tt.runList, treeprint.New()), qt.IsNil)
+
+			exp, err := h.ExpandRunList()
+			c.Assert(err, qt.IsNil)
+			c.Assert(exp, qt.DeepEquals, tt.expected)
+		})
+	}
+}
@@ -0,0 +1,138 @@
+// Package sarif implements the subset of the Static Analysis Results Interchange Format (SARIF)
+// 2.1.0 needed to report linting results to code scanning tools, which annotate the lines found
+// at fault.
+package sarif
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const (
+	// Version is the SARIF version implemented.
+	Version = "2.1.0"
+	// Schema is the JSON schema SARIF logs conform to.
+	Schema = "https://json.schemastore.org/sarif-2.1.0.json"
+	// SrcRoot is the base URI id relative artifact locations are resolved against,
+	// which code scanning tools set to the repository root.
+	SrcRoot = "%SRCROOT%"
+)
+
+// Result levels.
+const (
+	LevelError   = "error"
+	LevelWarning = "warning"
+	LevelNote    = "note"
+)
+
+// Log is the top level SARIF document.
+type Log struct {
+	Version string `json:"version"`
+	Schema  string `json:"$schema"`
+	Runs    []Run  `json:"runs"`
+}
+
+// Run is a single invocation of an analysis tool.
+type Run struct {
+	Tool    Tool     `json:"tool"`
+	Results []Result `json:"results"`
+}
+
+// Tool describes the analysis tool.
+type Tool struct {
+	Driver Driver `json:"driver"`
+}
+
+// Driver is the analysis tool's main component, along with the rules it checks.
+type Driver struct {
+	Name           string `json:"name"`
+	InformationURI string `json:"informationUri,omitempty"`
+	Rules          []Rule `json:"rules"`
+}
+
+// Rule describes a rule results are reported for.
+type Rule struct {
+	ID               string  `json:"id"`
+	ShortDescription Message `json:"shortDescription"`
+}
+
+// Message is a plain text message.
+type Message struct {
+	Text string `json:"text"`
+}
+
+// Result is a single rule violation.
+type Result struct {
+	RuleID           string     `json:"ruleId"`
+	Level            string     `json:"level,omitempty"`
+	Message          Message    `json:"message"`
+	Locations        []Location `json:"locations,omitempty"`
+	RelatedLocations []Location `json:"relatedLocations,omitempty"`
+}
+
+// Location is where a result was found.
+type Location struct {
+	ID               int              `json:"id,omitempty"`
+	PhysicalLocation PhysicalLocation `json:"physicalLocation"`
+	Message          *Message         `json:"message,omitempty"`
+}
+
+// PhysicalLocation is a location in a file.
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+	Region           *Region          `json:"region,omitempty"`
+}
+
+// ArtifactLocation is the URI of a file.
+type ArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+// Region is a region of a file, lines starting at 1.
+type Region struct {
+	StartLine int `json:"startLine"`
+}
+
+// New creates a log with a single run of the given tool.
+func New(driver Driver) *Log {
+	return &Log{
+		Version: Version,
+		Schema:  Schema,
+		Runs:    []Run{{Tool: Tool{Driver: driver}, Results: []Result{}}},
+	}
+}
+
+// AddResult adds a result to the log's run.
+func (l *Log) AddResult(r Result) {
+	l.Runs[0].Results = append(l.Runs[0].Results, r)
+}
+
+// NewLocation creates a location pointing to the given line of the file in uri, relative to
+// the repository root. The whole file is pointed to when line is 0.
+func NewLocation(uri string, line int, message string) Location {
+	loc := Location{
+		PhysicalLocation: PhysicalLocation{
+			ArtifactLocation: ArtifactLocation{URI: uri, URIBaseID: SrcRoot},
+		},
+	}
+
+	if line > 0 {
+		loc.PhysicalLocation.Region = &Region{StartLine: line}
+	}
+
+	if message != "" {
+		loc.Message = &Message{Text: message}
+	}
+
+	return loc
+}
+
+// Encode writes the log as indented JSON.
+func (l *Log) Encode(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+
+	return enc.Encode(l)
+}
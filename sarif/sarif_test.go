@@ -0,0 +1,57 @@
+package sarif
+
+import (
+	"bytes"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestLogEncode(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+
+	log := New(Driver{Name: "whisk", Rules: []Rule{{ID: "max-cycles", ShortDescription: Message{Text: "Cycles"}}}})
+	log.AddResult(Result{
+		RuleID:    "max-cycles",
+		Level:     LevelError,
+		Message:   Message{Text: "a -> b -> a"},
+		Locations: []Location{NewLocation("cookbooks/a/metadata.rb", 3, "")},
+	})
+	log.AddResult(Result{
+		RuleID:    "empty-run-list",
+		Message:   Message{Text: "run_list is empty"},
+		Locations: []Location{NewLocation("roles/web.json", 0, "")},
+	})
+
+	var buf bytes.Buffer
+	c.Assert(log.Encode(&buf), qt.IsNil)
+	c.Assert(buf.String(), qt.JSONEquals, map[string]any{
+		"version": "2.1.0",
+		"$schema": Schema,
+		"runs": []any{map[string]any{
+			"tool": map[string]any{"driver": map[string]any{
+				"name":  "whisk",
+				"rules": []any{map[string]any{"id": "max-cycles", "shortDescription": map[string]any{"text": "Cycles"}}},
+			}},
+			"results": []any{
+				map[string]any{
+					"ruleId":  "max-cycles",
+					"level":   "error",
+					"message": map[string]any{"text": "a -> b -> a"},
+					"locations": []any{map[string]any{"physicalLocation": map[string]any{
+						"artifactLocation": map[string]any{"uri": "cookbooks/a/metadata.rb", "uriBaseId": "%SRCROOT%"},
+						"region":           map[string]any{"startLine": 3},
+					}}},
+				},
+				map[string]any{
+					"ruleId":  "empty-run-list",
+					"message": map[string]any{"text": "run_list is empty"},
+					"locations": []any{map[string]any{"physicalLocation": map[string]any{
+						"artifactLocation": map[string]any{"uri": "roles/web.json", "uriBaseId": "%SRCROOT%"},
+					}}},
+				},
+			},
+		}},
+	})
+}
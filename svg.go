@@ -0,0 +1,295 @@
+package whisk
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strings"
+
+	"slack/whisk/graph/layout"
+)
+
+const (
+	// svgMargin is the space around the diagram.
+	svgMargin = 20
+	// svgCharWidth is the approximate width of a label's character.
+	svgCharWidth = 7
+	// svgVertexHeight is the height of a cookbook's box.
+	svgVertexHeight = 24
+	// svgVertexPadding is the horizontal space around a cookbook's label.
+	svgVertexPadding = 10
+	// svgClusterPadding is the space around the cookbooks of a strongly connected component.
+	svgClusterPadding = 12
+	// svgClusterLabelHeight is the height of a strongly connected component's label.
+	svgClusterLabelHeight = 18
+	// svgClusterGap is the space between the cookbooks of a strongly connected component.
+	svgClusterGap = 20
+	// svgCurvature is how far edges within strongly connected components bend, so opposite
+	// dependencies don't overlap.
+	svgCurvature = 12
+)
+
+// svgDiagram holds the dependency graph as laid out for SVG: every strongly connected component is
+// contracted into a cluster and the resulting DAG is laid out in layers, with the component's
+// cookbooks arranged in a grid within its cluster.
+type svgDiagram struct {
+	*diagram
+	// layout is the layout of the graph's condensation.
+	layout *layout.Layout
+	// boxes maps every vertex to its box.
+	boxes map[string]layout.Rect
+	// components maps every vertex to the vertex of the condensation it was contracted into.
+	components map[string]string
+}
+
+// sccComponent returns the condensation's vertex of a strongly connected component.
+func sccComponent(i int) string {
+	return fmt.Sprintf("scc:%d", i)
+}
+
+// vertexComponent returns the condensation's vertex of a cookbook outside strongly connected components.
+func vertexComponent(v string) string {
+	return "cookbook:" + v
+}
+
+// vertexSize returns the size of the vertex's box, fitting its label.
+func (h *Handler) vertexSize(v string) layout.Size {
+	return layout.Size{
+		Width:  float64(len([]rune(h.label(v)))*svgCharWidth + 2*svgVertexPadding),
+		Height: svgVertexHeight,
+	}
+}
+
+// clusterGrid returns the number of columns of the cluster's grid and the width of its cells, fitting
+// the widest cookbook of the strongly connected component.
+func (h *Handler) clusterGrid(scc []string) (int, float64) {
+	columns := int(math.Ceil(math.Sqrt(float64(len(scc)))))
+
+	cell := 0.0
+	for _, v := range scc {
+		cell = math.Max(cell, h.vertexSize(v).Width)
+	}
+
+	return columns, cell
+}
+
+// clusterSize returns the size of the strongly connected component's cluster.
+func (h *Handler) clusterSize(i int, scc []string) layout.Size {
+	columns, cell := h.clusterGrid(scc)
+	rows := (len(scc) + columns - 1) / columns
+
+	label := float64(len(sccLabel(i)) * svgCharWidth)
+	grid := float64(columns)*cell + float64(columns-1)*svgClusterGap
+
+	return layout.Size{
+		Width:  math.Max(grid, label) + 2*svgClusterPadding,
+		Height: float64(rows*svgVertexHeight+(rows-1)*svgClusterGap) + svgClusterLabelHeight + 2*svgClusterPadding,
+	}
+}
+
+// sccLabel returns the label of a strongly connected component, as dotTpl names them.
+func sccLabel(i int) string {
+	return fmt.Sprintf("Strongly Connected Subgraph %d", i)
+}
+
+// newSVGDiagram lays out the dependency graph for SVG.
+func (h *Handler) newSVGDiagram() (*svgDiagram, error) {
+	d := &svgDiagram{
+		diagram:    h.newDiagram(),
+		boxes:      make(map[string]layout.Rect),
+		components: make(map[string]string),
+	}
+	d.reduce(h.redundant)
+
+	sizes := make(map[string]layout.Size)
+	for i, scc := range h.sccs {
+		for _, v := range scc {
+			d.components[v] = sccComponent(i)
+		}
+		sizes[sccComponent(i)] = h.clusterSize(i, scc)
+	}
+
+	for _, v := range d.vertices {
+		if _, ok := d.components[v]; !ok {
+			d.components[v] = vertexComponent(v)
+			sizes[vertexComponent(v)] = h.vertexSize(v)
+		}
+	}
+
+	condensation := make(map[string][]string, len(sizes))
+	for c := range sizes {
+		condensation[c] = nil
+	}
+	for _, e := range d.edges {
+		if from, to := d.components[e[0]], d.components[e[1]]; from != to {
+			condensation[from] = append(condensation[from], to)
+		}
+	}
+
+	l, err := layout.NewSugiyama(condensation, func(c string) layout.Size { return sizes[c] }).Layout()
+	if err != nil {
+		return nil, fmt.Errorf("failed laying out graph: %w", err)
+	}
+	d.layout = l
+
+	for _, v := range d.vertices {
+		if _, ok := d.sccs[v]; !ok {
+			d.boxes[v] = l.Vertices[vertexComponent(v)]
+		}
+	}
+
+	for i, scc := range h.sccs {
+		cluster := l.Vertices[sccComponent(i)]
+		columns, cell := h.clusterGrid(scc)
+		grid := float64(columns)*cell + float64(columns-1)*svgClusterGap
+		left := cluster.X + (cluster.Width-grid)/2
+		top := cluster.Y + svgClusterPadding + svgClusterLabelHeight
+
+		for j, v := range sortedSCCVertices(scc) {
+			size := h.vertexSize(v)
+			col, row := j%columns, j/columns
+			d.boxes[v] = layout.Rect{
+				X:      left + float64(col)*(cell+svgClusterGap) + (cell-size.Width)/2,
+				Y:      top + float64(row)*(svgVertexHeight+svgClusterGap),
+				Width:  size.Width,
+				Height: size.Height,
+			}
+		}
+	}
+
+	return d, nil
+}
+
+// points returns the points the edge goes through. Edges between strongly connected components
+// follow the layout of the condensation, while edges within them go straight between both boxes.
+func (d *svgDiagram) points(e [2]string) []layout.Point {
+	from, to := d.boxes[e[0]], d.boxes[e[1]]
+
+	if d.components[e[0]] == d.components[e[1]] {
+		fx, fy := center(from)
+		tx, ty := center(to)
+
+		return []layout.Point{clip(from, tx, ty), clip(to, fx, fy)}
+	}
+
+	path := d.layout.Edges[[2]string{d.components[e[0]], d.components[e[1]]}]
+	points := append([]layout.Point(nil), path...)
+	points[0] = layout.Point{X: from.X + from.Width/2, Y: from.Y + from.Height}
+	points[len(points)-1] = layout.Point{X: to.X + to.Width/2, Y: to.Y}
+
+	return points
+}
+
+// center returns the center of the box.
+func center(r layout.Rect) (float64, float64) {
+	return r.X + r.Width/2, r.Y + r.Height/2
+}
+
+// clip returns where the line from the box's center to the given point crosses the box's border.
+func clip(r layout.Rect, x, y float64) layout.Point {
+	cx, cy := center(r)
+	dx, dy := x-cx, y-cy
+	if dx == 0 && dy == 0 {
+		return layout.Point{X: cx, Y: cy}
+	}
+
+	scale := math.Inf(1)
+	if dx != 0 {
+		scale = math.Min(scale, r.Width/2/math.Abs(dx))
+	}
+	if dy != 0 {
+		scale = math.Min(scale, r.Height/2/math.Abs(dy))
+	}
+
+	return layout.Point{X: cx + dx*scale, Y: cy + dy*scale}
+}
+
+// svgPath returns the SVG path going through the points. Curved paths bend sideways, so opposite
+// dependencies within strongly connected components don't overlap.
+func svgPath(points []layout.Point, curved bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "M%.1f,%.1f", points[0].X+svgMargin, points[0].Y+svgMargin)
+
+	if from, to := points[0], points[1]; curved && from != to {
+		dx, dy := to.X-from.X, to.Y-from.Y
+		length := math.Hypot(dx, dy)
+		cx := (from.X+to.X)/2 + dy/length*svgCurvature
+		cy := (from.Y+to.Y)/2 - dx/length*svgCurvature
+		fmt.Fprintf(&b, " Q%.1f,%.1f %.1f,%.1f", cx+svgMargin, cy+svgMargin, to.X+svgMargin, to.Y+svgMargin)
+
+		return b.String()
+	}
+
+	for _, p := range points[1:] {
+		fmt.Fprintf(&b, " L%.1f,%.1f", p.X+svgMargin, p.Y+svgMargin)
+	}
+
+	return b.String()
+}
+
+// SVG renders the dependency graph as an SVG image, laying it out without graphviz. Strongly
+// connected components are contracted to lay out the graph in layers, and drawn as clusters
+// colored the same way DOT does, while dependencies in cycles are highlighted.
+func (h *Handler) SVG(w io.Writer) error {
+	d, err := h.newSVGDiagram()
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	fmt.Fprintln(&b, `<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintf(&b, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%.0f\" height=\"%.0f\" viewBox=\"0 0 %.0f %.0f\" font-family=\"Helvetica, Arial, sans-serif\" font-size=\"11\">\n",
+		d.layout.Width+2*svgMargin, d.layout.Height+2*svgMargin, d.layout.Width+2*svgMargin, d.layout.Height+2*svgMargin)
+	fmt.Fprintln(&b, "  <defs>")
+	for _, m := range [][2]string{{"arrow", "#323538"}, {"cycle-arrow", cycleColor}} {
+		fmt.Fprintf(&b, "    <marker id=\"%s\" viewBox=\"0 0 10 10\" refX=\"10\" refY=\"5\" markerWidth=\"8\" markerHeight=\"8\" orient=\"auto-start-reverse\">", m[0])
+		fmt.Fprintf(&b, "<path d=\"M0,0 L10,5 L0,10 L4,5 z\" fill=\"%s\"/></marker>\n", m[1])
+	}
+	fmt.Fprintln(&b, "  </defs>")
+	fmt.Fprintln(&b, `  <rect width="100%" height="100%" fill="#ffffff"/>`)
+
+	for i := range h.sccs {
+		r := d.layout.Vertices[sccComponent(i)]
+		fmt.Fprintf(&b, "  <g class=\"scc\" id=\"scc%d\">\n", i)
+		fmt.Fprintf(&b, "    <rect x=\"%.1f\" y=\"%.1f\" width=\"%.1f\" height=\"%.1f\" fill=\"#F2C744\" stroke=\"#F2C744\"/>\n",
+			r.X+svgMargin, r.Y+svgMargin, r.Width, r.Height)
+		fmt.Fprintf(&b, "    <text x=\"%.1f\" y=\"%.1f\" text-anchor=\"middle\" fill=\"#323538\">%s</text>\n",
+			r.X+r.Width/2+svgMargin, r.Y+svgClusterPadding+svgClusterLabelHeight/2+svgMargin, sccLabel(i))
+		fmt.Fprintln(&b, "  </g>")
+	}
+
+	for _, e := range d.edges {
+		stroke, width, marker := "#323538", "0.75", "arrow"
+		if h.inCycle(e) {
+			stroke, width, marker = cycleColor, "1.5", "cycle-arrow"
+		}
+		fmt.Fprintf(&b, "  <path class=\"dependency\" d=\"%s\" fill=\"none\" stroke=\"%s\" stroke-width=\"%s\" marker-end=\"url(#%s)\"><title>%s -&gt; %s</title></path>\n",
+			svgPath(d.points(e), d.components[e[0]] == d.components[e[1]]), stroke, width, marker, html.EscapeString(e[0]), html.EscapeString(e[1]))
+	}
+
+	for _, v := range d.vertices {
+		r := d.boxes[v]
+		stroke, text, dash := "#323538", "#323538", ""
+		if _, ok := d.sccs[v]; ok {
+			stroke = "#F2C744"
+		}
+		if h.missing[v] {
+			text, dash = "#9A9A9A", ` stroke-dasharray="4 2"`
+		}
+
+		fmt.Fprintf(&b, "  <g class=\"cookbook\" id=\"%s\">\n", d.ids[v])
+		fmt.Fprintf(&b, "    <rect x=\"%.1f\" y=\"%.1f\" width=\"%.1f\" height=\"%.1f\" fill=\"#ffffff\" stroke=\"%s\"%s/>\n",
+			r.X+svgMargin, r.Y+svgMargin, r.Width, r.Height, stroke, dash)
+		fmt.Fprintf(&b, "    <text x=\"%.1f\" y=\"%.1f\" text-anchor=\"middle\" dominant-baseline=\"central\" fill=\"%s\">%s</text>\n",
+			r.X+r.Width/2+svgMargin, r.Y+r.Height/2+svgMargin, text, html.EscapeString(h.label(v)))
+		fmt.Fprintln(&b, "  </g>")
+	}
+	fmt.Fprintln(&b, "</svg>")
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed writing SVG: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package whisk
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/xlab/treeprint"
+)
+
+// svgElement is an element of an SVG document, along with its attributes and text, including its children's.
+type svgElement struct {
+	name  string
+	attrs map[string]string
+	text  string
+}
+
+// parseSVG returns the elements of an SVG document, in order, failing when it isn't well-formed XML.
+func parseSVG(c *qt.C, data []byte) []*svgElement {
+	var elements, stack []*svgElement
+
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return elements
+		}
+		c.Assert(err, qt.IsNil)
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			e := &svgElement{name: t.Name.Local, attrs: make(map[string]string)}
+			for _, a := range t.Attr {
+				e.attrs[a.Name.Local] = a.Value
+			}
+			elements = append(elements, e)
+			stack = append(stack, e)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			for _, e := range stack {
+				e.text += strings.TrimSpace(string(t))
+			}
+		}
+	}
+}
+
+func TestHandlerSVG(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"roles/web.json":              {Data: []byte(`{"name": "web", "run_list": ["recipe[app]"]}`)},
+		"cookbooks/app/metadata.rb":   {Data: []byte("name 'app'\ndepends 'nginx'\ndepends 'vault'\ndepends 'base'\n")},
+		"cookbooks/nginx/metadata.rb": {Data: []byte("name 'nginx'\ndepends 'ssl'\n")},
+		"cookbooks/ssl/metadata.rb":   {Data: []byte("name 'ssl'\ndepends 'nginx'\ndepends 'base'\n")},
+		"cookbooks/base/metadata.rb":  {Data: []byte("name 'base'\n")},
+	}
+
+	h := NewHandler([]string{"cookbooks"}, "roles", WithFS(fsys), WithAllowMissing())
+	qt.Assert(t, h.WalkRole("web", treeprint.New()), qt.IsNil)
+	qt.Assert(t, h.FindSCCs(), qt.IsNil)
+	qt.Assert(t, h.FindCycles(), qt.IsNil)
+
+	var b bytes.Buffer
+	qt.Assert(t, h.SVG(&b), qt.IsNil)
+	elements := parseSVG(qt.New(t), b.Bytes())
+
+	find := func(name, class string) []*svgElement {
+		var found []*svgElement
+		for _, e := range elements {
+			if e.name == name && e.attrs["class"] == class {
+				found = append(found, e)
+			}
+		}
+		return found
+	}
+
+	t.Run("it should draw strongly connected components as clusters", func(t *testing.T) {
+		t.Parallel()
+		c := qt.New(t)
+
+		c.Assert(find("g", "scc"), qt.HasLen, 1)
+
+		var labels []string
+		for _, e := range elements {
+			if e.name == "text" {
+				labels = append(labels, e.text)
+			}
+		}
+		c.Assert(labels, qt.DeepEquals, []string{"Strongly Connected Subgraph 0", "app", "base", "nginx", "ssl", "vault (missing)"})
+	})
+
+	t.Run("it should highlight dependencies in cycles", func(t *testing.T) {
+		t.Parallel()
+		c := qt.New(t)
+
+		strokes := make(map[string]string)
+		for _, e := range find("path", "dependency") {
+			strokes[e.text] = e.attrs["stroke"]
+		}
+		c.Assert(strokes, qt.DeepEquals, map[string]string{
+			"app -> base":  "#323538",
+			"app -> nginx": "#323538",
+			"app -> vault": "#323538",
+			"nginx -> ssl": cycleColor,
+			"ssl -> base":  "#323538",
+			"ssl -> nginx": cycleColor,
+		})
+	})
+
+	t.Run("it should lay out dependencies below the cookbooks depending on them", func(t *testing.T) {
+		t.Parallel()
+		c := qt.New(t)
+
+		y := make(map[string]float64)
+		for _, e := range elements {
+			if e.name == "text" {
+				v, err := strconv.ParseFloat(e.attrs["y"], 64)
+				c.Assert(err, qt.IsNil)
+				y[e.text] = v
+			}
+		}
+		c.Assert(y["app"] < y["nginx"], qt.IsTrue)
+		c.Assert(y["nginx"] == y["ssl"], qt.IsTrue)
+		c.Assert(y["ssl"] < y["base"], qt.IsTrue)
+	})
+}
@@ -0,0 +1,200 @@
+package whisk
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"slack/whisk/chef"
+)
+
+// Role validation rules.
+const (
+	// RuleNameMismatch reports roles whose name doesn't match their file name.
+	RuleNameMismatch = "role-name-mismatch"
+	// RuleJSONClass reports roles without json_class set to Chef::Role.
+	RuleJSONClass = "role-json-class"
+	// RuleChefType reports roles without chef_type set to role.
+	RuleChefType = "role-chef-type"
+	// RuleEmptyRunList reports roles with an empty run list.
+	RuleEmptyRunList = "empty-run-list"
+	// RuleInvalidEntry reports run list entries Chef can't parse.
+	RuleInvalidEntry = "invalid-run-list-entry"
+	// RuleDuplicateEntry reports run list entries repeated within the same run list.
+	RuleDuplicateEntry = "duplicate-run-list-entry"
+	// RuleMissingRole reports run list entries referencing roles not found in the roles directory.
+	RuleMissingRole = "missing-role"
+	// RuleMissingCookbook reports run list entries referencing cookbooks not found in any cookbook path.
+	RuleMissingCookbook = "missing-cookbook"
+	// RuleMissingRecipe reports run list entries referencing recipes not found in their cookbook.
+	RuleMissingRecipe = "missing-recipe"
+)
+
+// Rules describes every role validation rule.
+var Rules = map[string]string{
+	RuleNameMismatch:    "Role names must match their file names",
+	RuleJSONClass:       "Roles must set json_class to Chef::Role",
+	RuleChefType:        "Roles must set chef_type to role",
+	RuleEmptyRunList:    "Roles must have a run list",
+	RuleInvalidEntry:    "Run list entries must be valid",
+	RuleDuplicateEntry:  "Run list entries must not be repeated",
+	RuleMissingRole:     "Run lists must only reference existing roles",
+	RuleMissingCookbook: "Run lists must only reference existing cookbooks",
+	RuleMissingRecipe:   "Run lists must only reference existing recipes",
+}
+
+// Violation is a role not meeting a validation rule.
+type Violation struct {
+	// Rule is the rule violated.
+	Rule string `json:"rule"`
+	// Role is the name of the role violating the rule.
+	Role string `json:"role"`
+	// Path is the path of the role's file.
+	Path string `json:"path"`
+	// Message describes the violation.
+	Message string `json:"message"`
+	// Cookbook is the cookbook not found, for missing-cookbook violations.
+	Cookbook string `json:"cookbook,omitempty"`
+}
+
+// String formats the violation the way compilers do, prefixing it with the role's path.
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s [%s]", v.Path, v.Message, v.Rule)
+}
+
+// ValidateRoles validates every role walked, as well as the roles they include,
+// keeping the violations found for the output formats to report.
+func (h *Handler) ValidateRoles() error {
+	h.violations = nil
+	for _, name := range h.walkedRoles {
+		violations, err := h.ValidateRole(name)
+		if err != nil {
+			return err
+		}
+		h.violations = append(h.violations, violations...)
+	}
+
+	return nil
+}
+
+// reportedViolations returns the violations found validating roles, except those about cookbooks
+// recorded as missing, which are reported once along with the cookbooks requiring them instead.
+func (h *Handler) reportedViolations() []Violation {
+	var violations []Violation
+	for _, v := range h.violations {
+		if v.Rule == RuleMissingCookbook && h.missing[v.Cookbook] {
+			continue
+		}
+		violations = append(violations, v)
+	}
+
+	return violations
+}
+
+// ValidateRole validates a single role found in the roles directory against all the rules.
+func (h *Handler) ValidateRole(name string) ([]Violation, error) {
+	if len(h.rolesIndex) == 0 {
+		if err := h.loadRoles(); err != nil {
+			return nil, err
+		}
+	}
+
+	role, ok := h.rolesIndex[name]
+	if !ok {
+		return nil, fmt.Errorf("role %s doesn't exist", name)
+	}
+
+	var violations []Violation
+	report := func(rule, format string, a ...any) {
+		violations = append(violations, Violation{
+			Rule:    rule,
+			Role:    role.Name,
+			Path:    role.Path,
+			Message: fmt.Sprintf(format, a...),
+		})
+	}
+
+	if base := strings.TrimSuffix(path.Base(role.Path), ".json"); base != role.Name {
+		report(RuleNameMismatch, "role name %q doesn't match file name %q", role.Name, base)
+	}
+
+	if role.JSONClass != "Chef::Role" {
+		report(RuleJSONClass, "json_class must be %q, found %q", "Chef::Role", role.JSONClass)
+	}
+
+	if role.ChefType != "role" {
+		report(RuleChefType, "chef_type must be %q, found %q", "role", role.ChefType)
+	}
+
+	if len(role.RunList) == 0 {
+		report(RuleEmptyRunList, "run_list is empty")
+	}
+
+	seen := make(map[string]bool)
+	for _, entry := range role.RunList {
+		item, err := chef.ParseRunListItem(entry)
+		if err != nil {
+			report(RuleInvalidEntry, "%s", err)
+			continue
+		}
+
+		if seen[item.String()] {
+			report(RuleDuplicateEntry, "%q is included more than once", entry)
+			continue
+		}
+		seen[item.String()] = true
+
+		switch item.Type {
+		case chef.RoleItem:
+			if _, ok := h.rolesIndex[item.Name]; !ok {
+				report(RuleMissingRole, "%q references role %q, which doesn't exist", entry, item.Name)
+			}
+		case chef.RecipeItem:
+			cookbook := chef.NewCookbook(h.fsys, h.cookbookPaths, item.Name)
+			if err := cookbook.LoadDeps(); err != nil {
+				if !errors.Is(err, fs.ErrNotExist) {
+					return nil, fmt.Errorf("unable to load %q metadata: %w", item.Name, err)
+				}
+				report(RuleMissingCookbook, "%q references cookbook %q, which wasn't found in %q", entry, item.Name, h.cookbookPaths)
+				violations[len(violations)-1].Cookbook = item.Name
+				continue
+			}
+
+			found, err := hasRecipe(h.fsys, cookbook.Path, item.Recipe)
+			if err != nil {
+				return nil, err
+			}
+
+			if !found {
+				report(RuleMissingRecipe, "%q references recipe %q, which wasn't found in %q", entry, item.QualifiedRecipe(), cookbook.Path)
+			}
+		}
+	}
+
+	return violations, nil
+}
+
+// hasRecipe returns whether the cookbook in dir has the given recipe. Cookbooks served without their
+// recipes directory, such as cookbook artifacts or Chef Server's metadata, are assumed to have it.
+func hasRecipe(fsys fs.FS, dir, recipe string) (bool, error) {
+	recipes := path.Join(dir, "recipes")
+	if _, err := fs.Stat(fsys, recipes); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return true, nil
+		}
+
+		return false, fmt.Errorf("failed reading %q: %w", recipes, err)
+	}
+
+	if _, err := fs.Stat(fsys, path.Join(recipes, recipe+".rb")); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("failed reading recipe %q: %w", recipe, err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,68 @@
+package whisk
+
+import (
+	"testing"
+	"testing/fstest"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestHandlerValidateRole(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"roles/base.json":                    {Data: []byte(`{"name": "base", "json_class": "Chef::Role", "chef_type": "role", "run_list": ["recipe[apt]"]}`)},
+		"roles/empty.json":                   {Data: []byte(`{"name": "empty", "json_class": "Chef::Role", "chef_type": "role", "run_list": []}`)},
+		"roles/prod/web.json":                {Data: []byte(`{"name": "webserver", "run_list": ["role[base]", "role[db]", "recipe[nginx::source]", "nginx::source", "recipe[ssl]", "recipe[apt::missing]"]}`)},
+		"cookbooks/apt/metadata.rb":          {Data: []byte("name 'apt'\n")},
+		"cookbooks/apt/recipes/default.rb":   {Data: []byte("")},
+		"cookbooks/nginx/metadata.json":      {Data: []byte(`{"name": "nginx"}`)},
+		"cookbooks/nginx/recipes/default.rb": {Data: []byte("")},
+	}
+
+	tests := []struct {
+		name     string
+		role     string
+		expected []Violation
+	}{
+		{
+			"it should not report valid roles",
+			"base",
+			nil,
+		},
+		{
+			"it should report empty run lists",
+			"empty",
+			[]Violation{
+				{Rule: RuleEmptyRunList, Role: "empty", Path: "roles/empty.json", Message: "run_list is empty"},
+			},
+		},
+		{
+			"it should report every rule violated with the role's path",
+			"webserver",
+			[]Violation{
+				{Rule: RuleNameMismatch, Role: "webserver", Path: "roles/prod/web.json", Message: `role name "webserver" doesn't match file name "web"`},
+				{Rule: RuleJSONClass, Role: "webserver", Path: "roles/prod/web.json", Message: `json_class must be "Chef::Role", found ""`},
+				{Rule: RuleChefType, Role: "webserver", Path: "roles/prod/web.json", Message: `chef_type must be "role", found ""`},
+				{Rule: RuleMissingRole, Role: "webserver", Path: "roles/prod/web.json", Message: `"role[db]" references role "db", which doesn't exist`},
+				{Rule: RuleMissingRecipe, Role: "webserver", Path: "roles/prod/web.json", Message: `"recipe[nginx::source]" references recipe "nginx::source", which wasn't found in "cookbooks/nginx"`},
+				{Rule: RuleDuplicateEntry, Role: "webserver", Path: "roles/prod/web.json", Message: `"nginx::source" is included more than once`},
+				{Rule: RuleMissingCookbook, Role: "webserver", Path: "roles/prod/web.json", Message: `"recipe[ssl]" references cookbook "ssl", which wasn't found in ["cookbooks"]`, Cookbook: "ssl"},
+				{Rule: RuleMissingRecipe, Role: "webserver", Path: "roles/prod/web.json", Message: `"recipe[apt::missing]" references recipe "apt::missing", which wasn't found in "cookbooks/apt"`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := qt.New(t)
+
+			h := NewHandler([]string{"cookbooks"}, "roles", WithFS(fsys))
+			violations, err := h.ValidateRole(tt.role)
+			c.Assert(err, qt.IsNil)
+			c.Assert(violations, qt.DeepEquals, tt.expected)
+		})
+	}
+}